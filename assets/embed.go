@@ -0,0 +1,31 @@
+// Package assets embeds the dashboard's html, css, scripts, images and
+// hive.wasm so the server can be shipped as a single binary. Build hive.wasm
+// into this folder before building the server.
+package assets
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+)
+
+//go:embed *
+var files embed.FS
+
+// FS returns the embedded dashboard files.
+func FS() fs.FS {
+	return assetFS{files}
+}
+
+// assetFS hides the files of this package that only exist for the Go build.
+type assetFS struct {
+	fs.FS
+}
+
+func (a assetFS) Open(name string) (fs.File, error) {
+	base := path.Base(name)
+	if base == "go.mod" || path.Ext(base) == ".go" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return a.FS.Open(name)
+}
@@ -0,0 +1,3 @@
+module github.com/StreamSpace/hive-wasm-client/assets
+
+go 1.16
@@ -0,0 +1,385 @@
+// Minimal DOM shim for running hive.wasm under Node. It provides the parts
+// of document, window, location and localStorage the wasm client uses, with
+// one element for every id found in an HTML page.
+"use strict";
+
+const fs = require("fs");
+
+class Style {
+	get cssText() {
+		return Object.keys(this)
+			.map((name) => `${name}: ${this[name]};`)
+			.join(" ");
+	}
+
+	set cssText(text) {
+		for (const name of Object.keys(this)) {
+			delete this[name];
+		}
+		for (const declaration of String(text).split(";")) {
+			const idx = declaration.indexOf(":");
+			if (idx < 0) {
+				continue;
+			}
+			this[declaration.slice(0, idx).trim()] = declaration.slice(idx + 1).trim();
+		}
+	}
+}
+
+class Element {
+	constructor(document, tagName, attributes) {
+		this.ownerDocument = document;
+		this.tagName = tagName.toUpperCase();
+		this.children = [];
+		this.parentNode = null;
+		this.listeners = {};
+		this.attributes = Object.assign({}, attributes);
+		this._style = new Style();
+		this._text = "";
+		this._html = "";
+		this.id = this.attributes.id || "";
+		this.className = this.attributes.class || "";
+		this.value = this.attributes.value || "";
+		this.placeholder = this.attributes.placeholder || "";
+		this.min = this.attributes.min || "";
+		this.max = this.attributes.max || "";
+	}
+
+	get style() {
+		return this._style;
+	}
+
+	// the wasm client assigns inline styles as strings
+	set style(text) {
+		this._style.cssText = text;
+	}
+
+	get textContent() {
+		return this._text + this.children.map((child) => child.textContent).join("");
+	}
+
+	set textContent(text) {
+		this.children = [];
+		this._text = String(text);
+		this._html = this._text;
+	}
+
+	get innerHTML() {
+		return this._html;
+	}
+
+	// markup isn't parsed, the text of the element is the markup as is
+	set innerHTML(html) {
+		this.children = [];
+		this._html = String(html);
+		this._text = this._html;
+	}
+
+	getAttribute(name) {
+		return name in this.attributes ? this.attributes[name] : null;
+	}
+
+	setAttribute(name, value) {
+		this.attributes[name] = String(value);
+	}
+
+	appendChild(child) {
+		child.parentNode = this;
+		this.children.push(child);
+		if (child.id) {
+			this.ownerDocument.elements[child.id] = child;
+		}
+		return child;
+	}
+
+	remove() {
+		if (this.parentNode) {
+			this.parentNode.children = this.parentNode.children.filter((child) => child !== this);
+			this.parentNode = null;
+		}
+	}
+
+	// clicking a link with a download attribute records the download
+	click() {
+		if (this.tagName === "A" && this.download) {
+			this.ownerDocument.downloads.push({ name: this.download, href: this.href });
+		}
+	}
+
+	addEventListener(type, listener) {
+		(this.listeners[type] = this.listeners[type] || []).push(listener);
+	}
+
+	removeEventListener(type, listener) {
+		this.listeners[type] = (this.listeners[type] || []).filter((l) => l !== listener);
+	}
+
+	snapshot() {
+		return {
+			tag: this.tagName.toLowerCase(),
+			textContent: this.textContent,
+			innerHTML: this.innerHTML,
+			value: this.value,
+			placeholder: this.placeholder,
+			className: this.className,
+			style: this.style.cssText,
+			children: this.children.map((child) => child.snapshot()),
+		};
+	}
+}
+
+class Document {
+	constructor() {
+		this.elements = {};
+		this.readyState = "complete";
+		this.downloads = [];
+		this.body = new Element(this, "body", {});
+	}
+
+	getElementById(id) {
+		return this.elements[id] || null;
+	}
+
+	createElement(tagName) {
+		return new Element(this, tagName, {});
+	}
+
+	addEventListener() {}
+
+	removeEventListener() {}
+
+	snapshot() {
+		const result = {};
+		for (const id of Object.keys(this.elements)) {
+			result[id] = this.elements[id].snapshot();
+		}
+		return result;
+	}
+}
+
+// loadPage creates an element for every tag with an id in the HTML file,
+// keeping the value, placeholder, min and max attributes they start with.
+function loadPage(document, file) {
+	const html = fs.readFileSync(file, "utf8");
+	const tag = /<([a-zA-Z][a-zA-Z0-9]*)\b([^>]*?)\bid\s*=\s*"([^"]+)"([^>]*)>/g;
+	const attribute = /([a-zA-Z-]+)\s*=\s*"([^"]*)"/g;
+	let match;
+	while ((match = tag.exec(html)) !== null) {
+		const attributes = {};
+		const rest = match[2] + " " + match[4];
+		let attr;
+		while ((attr = attribute.exec(rest)) !== null) {
+			attributes[attr[1]] = attr[2];
+		}
+		attributes.id = match[3];
+		document.elements[match[3]] = new Element(document, match[1], attributes);
+	}
+}
+
+class Storage {
+	constructor() {
+		this.items = {};
+	}
+
+	getItem(key) {
+		return key in this.items ? this.items[key] : null;
+	}
+
+	setItem(key, value) {
+		this.items[key] = String(value);
+	}
+
+	removeItem(key) {
+		delete this.items[key];
+	}
+}
+
+// compareKeys orders IndexedDB keys, strings, numbers or arrays of them
+function compareKeys(a, b) {
+	if (Array.isArray(a) && Array.isArray(b)) {
+		for (let i = 0; i < a.length && i < b.length; i++) {
+			const order = compareKeys(a[i], b[i]);
+			if (order !== 0) {
+				return order;
+			}
+		}
+		return a.length - b.length;
+	}
+	return a < b ? -1 : a > b ? 1 : 0;
+}
+
+// complete runs fn after the current task and reports its result or error
+// on request, as IndexedDB requests do
+function complete(request, fn) {
+	setTimeout(() => {
+		try {
+			request.result = fn();
+		} catch (err) {
+			request.error = err;
+			if (request.onerror) {
+				request.onerror({ target: request });
+			}
+			return;
+		}
+		if (request.onsuccess) {
+			request.onsuccess({ target: request });
+		}
+	}, 0);
+	return request;
+}
+
+class ObjectStore {
+	constructor(keyPath, indexes, records) {
+		this.keyPath = keyPath;
+		this.indexes = indexes || {};
+		this.records = records || [];
+	}
+
+	key(value) {
+		return Array.isArray(this.keyPath) ? this.keyPath.map((name) => value[name]) : value[this.keyPath];
+	}
+
+	find(key) {
+		return this.records.findIndex((value) => compareKeys(this.key(value), key) === 0);
+	}
+
+	createIndex(name, keyPath) {
+		this.indexes[name] = keyPath;
+		return {};
+	}
+
+	get(key) {
+		return complete({}, () => {
+			const i = this.find(key);
+			return i < 0 ? undefined : JSON.parse(JSON.stringify(this.records[i]));
+		});
+	}
+
+	put(value) {
+		return complete({}, () => {
+			const copy = JSON.parse(JSON.stringify(value));
+			const i = this.find(this.key(copy));
+			if (i < 0) {
+				this.records.push(copy);
+				this.records.sort((a, b) => compareKeys(this.key(a), this.key(b)));
+			} else {
+				this.records[i] = copy;
+			}
+			return this.key(copy);
+		});
+	}
+
+	delete(key) {
+		return complete({}, () => {
+			const i = this.find(key);
+			if (i >= 0) {
+				this.records.splice(i, 1);
+			}
+			return undefined;
+		});
+	}
+
+	index(name) {
+		const keyPath = this.indexes[name];
+		return {
+			getAll: (key) =>
+				complete({}, () =>
+					this.records.filter((value) => compareKeys(value[keyPath], key) === 0).map((value) => JSON.parse(JSON.stringify(value)))
+				),
+		};
+	}
+}
+
+class Database {
+	constructor(version, stores) {
+		this.version = version;
+		this.stores = stores || {};
+		const names = this.stores;
+		this.objectStoreNames = { contains: (name) => name in names };
+	}
+
+	createObjectStore(name, options) {
+		this.stores[name] = new ObjectStore(options.keyPath);
+		return this.stores[name];
+	}
+
+	transaction(name) {
+		return { objectStore: () => this.stores[name] };
+	}
+
+	close() {}
+}
+
+// IndexedDB keeps databases in memory with enough of the API for the history
+// of the wasm client. dump returns them as JSON so a run can be restored.
+class IndexedDB {
+	constructor(dumped) {
+		this.databases = {};
+		for (const [name, db] of Object.entries(dumped || {})) {
+			const stores = {};
+			for (const [storeName, store] of Object.entries(db.stores)) {
+				stores[storeName] = new ObjectStore(store.keyPath, store.indexes, store.records);
+			}
+			this.databases[name] = new Database(db.version, stores);
+		}
+	}
+
+	open(name, version) {
+		const request = {};
+		setTimeout(() => {
+			let db = this.databases[name];
+			if (!db || db.version < version) {
+				db = db || new Database(version);
+				db.version = version;
+				this.databases[name] = db;
+				request.result = db;
+				if (request.onupgradeneeded) {
+					request.onupgradeneeded({ target: request });
+				}
+			}
+			request.result = db;
+			if (request.onsuccess) {
+				request.onsuccess({ target: request });
+			}
+		}, 0);
+		return request;
+	}
+
+	dump() {
+		const dumped = {};
+		for (const [name, db] of Object.entries(this.databases)) {
+			const stores = {};
+			for (const [storeName, store] of Object.entries(db.stores)) {
+				stores[storeName] = { keyPath: store.keyPath, indexes: store.indexes, records: store.records };
+			}
+			dumped[name] = { version: db.version, stores };
+		}
+		return dumped;
+	}
+}
+
+// install makes globalThis look like the window of page served at href
+function install(page, href) {
+	const document = new Document();
+	loadPage(document, page);
+	const events = new EventTarget();
+	globalThis.window = globalThis;
+	globalThis.document = document;
+	globalThis.localStorage = new Storage();
+	globalThis.addEventListener = events.addEventListener.bind(events);
+	globalThis.removeEventListener = events.removeEventListener.bind(events);
+	globalThis.dispatchEvent = events.dispatchEvent.bind(events);
+	const url = new URL(href);
+	globalThis.location = {
+		href: url.href,
+		origin: url.origin,
+		protocol: url.protocol,
+		search: url.search,
+		reload() {
+			globalThis.location.reloaded = true;
+		},
+	};
+	return document;
+}
+
+module.exports = { install, Document, Element, IndexedDB };
@@ -0,0 +1,198 @@
+// Runs hive.wasm under Node against a daemon, performs the given steps on
+// the page and prints the elements of the page as JSON.
+//
+//   node harness.js --wasm hive.wasm --page ../assets/index.html \
+//     --url http://127.0.0.1:4343/index.html [steps...]
+//
+// Steps run in order once Start() resolved:
+//
+//   --until ID          wait until element ID has text or children
+//   --set ID.PROP=VALUE set a property of an element
+//   --call NAME[:ARGS]  call a function registered by the wasm client, with
+//                       the comma separated string ARGS
+//   --wait MS           sleep
+//
+// --wasm-exec selects the wasm_exec.js to load, assets/wasm_exec.js by
+// default. It has to come from the Go release hive.wasm was built with.
+//
+// --indexeddb FILE loads the IndexedDB databases of the page from FILE, if
+// it exists, and saves them back when the steps are done, so a second run
+// sees what the first one stored as after a reload.
+"use strict";
+
+const fs = require("fs");
+const { resolveObjectURL } = require("buffer");
+const path = require("path");
+const dom = require("./dom.js");
+
+const nodeProcess = process;
+
+function usage(message) {
+	nodeProcess.stderr.write(`harness: ${message}\n`);
+	nodeProcess.exit(2);
+}
+
+function parseArgs(argv) {
+	const options = {
+		wasmExec: path.join(__dirname, "..", "assets", "wasm_exec.js"),
+		start: "",
+		timeout: 10000,
+		steps: [],
+	};
+	for (let i = 0; i < argv.length; i++) {
+		const flag = argv[i];
+		const value = argv[++i];
+		if (value === undefined) {
+			usage(`missing value of ${flag}`);
+		}
+		switch (flag) {
+			case "--wasm":
+				options.wasm = value;
+				break;
+			case "--wasm-exec":
+				options.wasmExec = value;
+				break;
+			case "--page":
+				options.page = value;
+				break;
+			case "--url":
+				options.url = value;
+				break;
+			case "--start":
+				options.start = value;
+				break;
+			case "--indexeddb":
+				options.indexedDB = value;
+				break;
+			case "--timeout":
+				options.timeout = Number(value);
+				break;
+			case "--until":
+			case "--set":
+			case "--call":
+			case "--wait":
+				options.steps.push({ step: flag.slice(2), value });
+				break;
+			default:
+				usage(`unknown flag ${flag}`);
+		}
+	}
+	for (const required of ["wasm", "page", "url"]) {
+		if (!options[required]) {
+			usage(`--${required} is required`);
+		}
+	}
+	return options;
+}
+
+const sleep = (ms) => new Promise((resolve) => setTimeout(resolve, ms));
+
+async function until(id, timeout) {
+	const deadline = Date.now() + timeout;
+	while (Date.now() < deadline) {
+		const element = document.getElementById(id);
+		if (element && (element.textContent !== "" || element.children.length > 0)) {
+			return;
+		}
+		await sleep(50);
+	}
+	throw new Error(`timed out waiting for #${id}`);
+}
+
+async function runStep(step, options) {
+	switch (step.step) {
+		case "until":
+			return until(step.value, options.timeout);
+		case "wait":
+			return sleep(Number(step.value));
+		case "call": {
+			const [name, ...rest] = step.value.split(":");
+			const args = rest.length ? rest.join(":").split(",") : [];
+			const fn = globalThis[name];
+			if (typeof fn !== "function") {
+				throw new Error(`${name} is not defined`);
+			}
+			return fn(...args);
+		}
+		case "set": {
+			const match = /^([^.]+)\.([^=]+)=(.*)$/.exec(step.value);
+			if (!match) {
+				throw new Error(`invalid --set ${step.value}, expected ID.PROP=VALUE`);
+			}
+			const element = document.getElementById(match[1]);
+			if (!element) {
+				throw new Error(`no element #${match[1]}`);
+			}
+			element[match[2]] = match[3];
+			return;
+		}
+	}
+}
+
+async function main() {
+	const options = parseArgs(nodeProcess.argv.slice(2));
+	dom.install(options.page, options.url);
+	if (options.indexedDB) {
+		const saved = fs.existsSync(options.indexedDB) ? JSON.parse(fs.readFileSync(options.indexedDB, "utf8")) : {};
+		globalThis.indexedDB = new dom.IndexedDB(saved);
+	}
+
+	// Go disables fetch when it detects Node by process.argv0, which leaves
+	// net/http without a transport in wasm
+	globalThis.process = Object.create(nodeProcess, { argv0: { value: "hive-harness" } });
+
+	// functions the pages define in their own scripts
+	const pageCalls = [];
+	for (const name of ["CreateGraph", "SliderColour", "CloseBanner", "DrawBandwidthChart"]) {
+		globalThis[name] = (...args) => {
+			pageCalls.push({ name, args });
+		};
+	}
+
+	globalThis.require = require;
+	globalThis.fs = fs;
+	globalThis.path = path;
+	require(path.resolve(options.wasmExec));
+	const go = new Go();
+	const result = await WebAssembly.instantiate(fs.readFileSync(options.wasm), go.importObject);
+	go.run(result.instance);
+
+	const timer = setTimeout(() => {
+		nodeProcess.stderr.write("harness: timed out waiting for Start\n");
+		nodeProcess.exit(1);
+	}, options.timeout);
+	const ready = options.start ? await Start(options.start) : await Start();
+	clearTimeout(timer);
+
+	for (const step of options.steps) {
+		await runStep(step, options);
+	}
+	if (options.indexedDB) {
+		fs.writeFileSync(options.indexedDB, JSON.stringify(globalThis.indexedDB.dump()));
+	}
+	const downloads = [];
+	for (const download of document.downloads) {
+		const blob = resolveObjectURL(download.href);
+		downloads.push({
+			name: download.name,
+			type: blob ? blob.type : "",
+			content: blob ? await blob.text() : "",
+		});
+	}
+	nodeProcess.stdout.write(
+		JSON.stringify({
+			ready,
+			elements: document.snapshot(),
+			storage: localStorage.items,
+			pageCalls,
+			downloads,
+		}) + "\n",
+		// writes to a pipe are asynchronous, exiting right away truncates them
+		() => nodeProcess.exit(0)
+	);
+}
+
+main().catch((err) => {
+	nodeProcess.stderr.write(`harness: ${err.stack || err}\n`);
+	nodeProcess.exit(1);
+});
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/StreamSpace/hive-wasm-client/assets"
+)
+
+// Assets returns the dashboard files from dir, or the ones embedded in the
+// binary when dir is empty.
+func Assets(dir string) fs.FS {
+	var files fs.FS
+	if dir != "" {
+		fmt.Println("Serving assets from", dir)
+		files = os.DirFS(dir)
+	} else {
+		files = assets.FS()
+	}
+	_, err := fs.Stat(files, "hive.wasm")
+	if err != nil {
+		fmt.Println("hive.wasm not found, build it with: cd wasm && GOOS=js GOARCH=wasm go build -o ../assets/hive.wasm")
+	}
+	return files
+}
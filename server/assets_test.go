@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssets(t *testing.T) {
+	embedded := Assets("")
+	if _, err := fs.Stat(embedded, "index.html"); err != nil {
+		t.Error(err)
+	}
+	// the Go files of the assets package aren't served
+	for _, name := range []string{"embed.go", "go.mod"} {
+		if _, err := fs.Stat(embedded, name); err == nil {
+			t.Errorf("%s is served", name)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := fs.ReadFile(Assets(dir), "index.html")
+	if err != nil || string(buf) != "<html></html>" {
+		t.Errorf("got %q, %v", buf, err)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookie = "hive_session"
+	csrfHeader    = "X-CSRF-Token"
+	sessionTTL    = 12 * time.Hour
+	loginDelay    = time.Second
+)
+
+// Auth gates the daemon endpoints behind a login. Users either sign in with a
+// password from the users file, or with the bearer token which can also be
+// sent directly in the Authorization header by scripts.
+type Auth struct {
+	mtx      sync.Mutex
+	users    map[string][]byte
+	token    string
+	secure   bool
+	sessions map[string]*session
+}
+
+type session struct {
+	user    string
+	csrf    string
+	expires time.Time
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+}
+
+type sessionResponse struct {
+	User string `json:"user"`
+	CSRF string `json:"csrf"`
+}
+
+// dummyHash is compared against when the user does not exist, so that
+// unknown and known users take the same time to reject.
+var dummyHash = []byte("$2a$10$09OiWe6hL1DfM14zhPoLa.1qx3RwnRpdHeNHjLrzD3oRwejHooL0W")
+
+// NewAuth returns an Auth for the users in usersFile and the bearer token,
+// either of which may be empty.
+func NewAuth(usersFile, token string) (*Auth, error) {
+	a := &Auth{
+		users:    make(map[string][]byte),
+		token:    token,
+		sessions: make(map[string]*session),
+	}
+	if usersFile != "" {
+		users, err := LoadUsers(usersFile)
+		if err != nil {
+			return nil, err
+		}
+		a.users = users
+	}
+	if len(a.users) == 0 && a.token == "" {
+		return nil, fmt.Errorf("no users or token configured")
+	}
+	return a, nil
+}
+
+// LoadUsers reads a users file with one "username:bcrypt-hash" per line.
+// Empty lines and lines starting with # are ignored.
+func LoadUsers(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	users := make(map[string][]byte)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		idx := strings.Index(text, ":")
+		if idx < 1 {
+			return nil, fmt.Errorf("%s:%d: expected username:hash", path, line)
+		}
+		hash := []byte(text[idx+1:])
+		_, err := bcrypt.Cost(hash)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, line, err.Error())
+		}
+		users[text[:idx]] = hash
+	}
+	return users, scanner.Err()
+}
+
+// HashPassword returns the users file hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// SetSecure marks session cookies as https only.
+func (a *Auth) SetSecure(secure bool) {
+	a.secure = secure
+}
+
+// Register mounts the login, logout and session endpoints on mux.
+func (a *Auth) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/auth/login", a.Login)
+	mux.HandleFunc("/auth/logout", a.Logout)
+	mux.HandleFunc("/auth/session", a.Session)
+}
+
+// Protect only lets authenticated requests through to next. Requests
+// authenticated by a session cookie must also carry the session's CSRF token
+// unless they are safe.
+func (a *Auth) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.bearer(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s := a.session(r)
+		if s == nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !equal(r.Header.Get(csrfHeader), s.csrf) {
+			http.Error(w, "invalid csrf token", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Login starts a session for a valid username and password or token.
+func (a *Auth) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req loginRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req)
+	if err != nil {
+		http.Error(w, "invalid login request", http.StatusBadRequest)
+		return
+	}
+	user, ok := a.verify(req)
+	if !ok {
+		time.Sleep(loginDelay)
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	id, s := a.newSession(user)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		Expires:  s.expires,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	writeJSON(w, sessionResponse{User: s.user, CSRF: s.csrf})
+}
+
+// Logout ends the current session.
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := a.session(r)
+	if s == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !equal(r.Header.Get(csrfHeader), s.csrf) {
+		http.Error(w, "invalid csrf token", http.StatusForbidden)
+		return
+	}
+	cookie, _ := r.Cookie(sessionCookie)
+	a.mtx.Lock()
+	delete(a.sessions, cookie.Value)
+	a.mtx.Unlock()
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Session returns the user and CSRF token of the current session.
+func (a *Auth) Session(w http.ResponseWriter, r *http.Request) {
+	s := a.session(r)
+	if s == nil {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, sessionResponse{User: s.user, CSRF: s.csrf})
+}
+
+func (a *Auth) verify(req loginRequest) (string, bool) {
+	if req.Token != "" {
+		return "token", a.token != "" && equal(req.Token, a.token)
+	}
+	hash, found := a.users[req.Username]
+	if !found {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(req.Password))
+		return "", false
+	}
+	return req.Username, bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) == nil
+}
+
+func (a *Auth) bearer(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if a.token == "" || !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	return equal(strings.TrimPrefix(header, "Bearer "), a.token)
+}
+
+func (a *Auth) session(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	s, found := a.sessions[cookie.Value]
+	if !found {
+		return nil
+	}
+	if time.Now().After(s.expires) {
+		delete(a.sessions, cookie.Value)
+		return nil
+	}
+	return s
+}
+
+func (a *Auth) newSession(user string) (string, *session) {
+	s := &session{
+		user:    user,
+		csrf:    randomToken(),
+		expires: time.Now().Add(sessionTTL),
+	}
+	id := randomToken()
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	for key, old := range a.sessions {
+		if time.Now().After(old.expires) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[id] = s
+	return id, s
+}
+
+func randomToken() string {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
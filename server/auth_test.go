@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authServer serves a daemon endpoint behind auth for alice with the
+// password "wonderland", or the bearer token "s3cret"
+func authServer(t *testing.T) (*Auth, *httptest.Server) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("wonderland"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := filepath.Join(t.TempDir(), "users")
+	err = os.WriteFile(users, []byte("# dashboard users\n\nalice:"+string(hash)+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := NewAuth(users, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	auth.Register(mux)
+	mux.Handle("/v3/", auth.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return auth, srv
+}
+
+func newBrowser(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+func send(t *testing.T, c *http.Client, method, url string, body interface{}, header http.Header) *http.Response {
+	t.Helper()
+	buf, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// login signs alice in and returns the CSRF token of her session
+func login(t *testing.T, c *http.Client, srv *httptest.Server) string {
+	t.Helper()
+	buf, _ := json.Marshal(loginRequest{Username: "alice", Password: "wonderland"})
+	resp, err := c.Post(srv.URL+"/auth/login", "application/json", bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login: got status %d", resp.StatusCode)
+	}
+	var s sessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.User != "alice" || s.CSRF == "" {
+		t.Fatalf("login: got %+v", s)
+	}
+	return s.CSRF
+}
+
+func TestAuthLogin(t *testing.T) {
+	_, srv := authServer(t)
+	for _, tc := range []struct {
+		name string
+		req  loginRequest
+		want int
+	}{
+		{"wrong password", loginRequest{Username: "alice", Password: "looking glass"}, http.StatusUnauthorized},
+		{"wrong token", loginRequest{Token: "guess"}, http.StatusUnauthorized},
+		{"token", loginRequest{Token: "s3cret"}, http.StatusOK},
+		{"password", loginRequest{Username: "alice", Password: "wonderland"}, http.StatusOK},
+	} {
+		c := newBrowser(t)
+		if resp := send(t, c, http.MethodPost, srv.URL+"/auth/login", tc.req, nil); resp.StatusCode != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+		want := http.StatusUnauthorized
+		if tc.want == http.StatusOK {
+			want = http.StatusOK
+		}
+		if resp := send(t, c, http.MethodGet, srv.URL+"/auth/session", nil, nil); resp.StatusCode != want {
+			t.Errorf("%s: session got status %d, want %d", tc.name, resp.StatusCode, want)
+		}
+	}
+	if resp := send(t, newBrowser(t), http.MethodGet, srv.URL+"/auth/login", nil, nil); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET login: got status %d", resp.StatusCode)
+	}
+}
+
+func TestAuthProtect(t *testing.T) {
+	_, srv := authServer(t)
+	c := newBrowser(t)
+	execute := srv.URL + "/v3/execute"
+	if resp := send(t, c, http.MethodPost, execute, nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("without a session: got status %d", resp.StatusCode)
+	}
+	csrf := login(t, c, srv)
+	for _, tc := range []struct {
+		name   string
+		method string
+		csrf   string
+		want   int
+	}{
+		{"missing csrf token", http.MethodPost, "", http.StatusForbidden},
+		{"wrong csrf token", http.MethodPost, csrf + "x", http.StatusForbidden},
+		{"csrf token", http.MethodPost, csrf, http.StatusOK},
+		// safe requests need no token
+		{"get", http.MethodGet, "", http.StatusOK},
+	} {
+		header := make(http.Header)
+		if tc.csrf != "" {
+			header.Set(csrfHeader, tc.csrf)
+		}
+		if resp := send(t, c, tc.method, execute, nil, header); resp.StatusCode != tc.want {
+			t.Errorf("%s: got status %d, want %d", tc.name, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestAuthBearer(t *testing.T) {
+	_, srv := authServer(t)
+	execute := srv.URL + "/v3/execute"
+	for _, tc := range []struct {
+		header string
+		want   int
+	}{
+		{"Bearer s3cret", http.StatusOK},
+		{"Bearer guess", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"s3cret", http.StatusUnauthorized},
+	} {
+		header := http.Header{"Authorization": {tc.header}}
+		// scripts need no session nor CSRF token
+		if resp := send(t, http.DefaultClient, http.MethodPost, execute, nil, header); resp.StatusCode != tc.want {
+			t.Errorf("%q: got status %d, want %d", tc.header, resp.StatusCode, tc.want)
+		}
+	}
+}
+
+func TestAuthExpiredSession(t *testing.T) {
+	auth, srv := authServer(t)
+	c := newBrowser(t)
+	csrf := login(t, c, srv)
+	header := http.Header{csrfHeader: {csrf}}
+	auth.mtx.Lock()
+	for _, s := range auth.sessions {
+		s.expires = time.Now().Add(-time.Second)
+	}
+	auth.mtx.Unlock()
+	if resp := send(t, c, http.MethodPost, srv.URL+"/v3/execute", nil, header); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	auth.mtx.Lock()
+	defer auth.mtx.Unlock()
+	if len(auth.sessions) != 0 {
+		t.Errorf("the expired session was kept")
+	}
+}
+
+func TestAuthLogout(t *testing.T) {
+	_, srv := authServer(t)
+	c := newBrowser(t)
+	csrf := login(t, c, srv)
+	if resp := send(t, c, http.MethodPost, srv.URL+"/auth/logout", nil, nil); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("logout without csrf token: got status %d", resp.StatusCode)
+	}
+	header := http.Header{csrfHeader: {csrf}}
+	if resp := send(t, c, http.MethodPost, srv.URL+"/auth/logout", nil, header); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("logout: got status %d", resp.StatusCode)
+	}
+	if resp := send(t, c, http.MethodPost, srv.URL+"/v3/execute", nil, header); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("after logout: got status %d", resp.StatusCode)
+	}
+	if resp := send(t, c, http.MethodPost, srv.URL+"/auth/logout", nil, header); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("second logout: got status %d", resp.StatusCode)
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	if _, err := NewAuth("", ""); err == nil {
+		t.Error("no users or token: got no error")
+	}
+	users := filepath.Join(t.TempDir(), "users")
+	if err := os.WriteFile(users, []byte("alice:plaintext\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewAuth(users, ""); err == nil {
+		t.Error("a password instead of a hash: got no error")
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+)
+
+// Config of the dashboard server. Values are read from, in increasing order
+// of precedence, the defaults, the JSON config file, HIVE_DASHBOARD_*
+// environment variables and command line flags.
+type Config struct {
+	Listen     string `json:"listen"`
+	Assets     string `json:"assets"`
+	Upstream   string `json:"upstream"`
+	Mock       bool   `json:"mock"`
+	Users      string `json:"users"`
+	Token      string `json:"token"`
+	TLSCert    string `json:"tlsCert"`
+	TLSKey     string `json:"tlsKey"`
+	SelfSigned bool   `json:"selfSigned"`
+
+	HashPassword bool `json:"-"`
+}
+
+const (
+	envPrefix             = "HIVE_DASHBOARD_"
+	defaultSelfSignedCert = "dashboard.crt"
+	defaultSelfSignedKey  = "dashboard.key"
+)
+
+// DefaultConfig listens on localhost only. Listening on other interfaces
+// publishes the daemon proxy on the network, which main only allows with
+// Users or Token set.
+func DefaultConfig() *Config {
+	return &Config{
+		Listen:   "localhost:9090",
+		Upstream: "http://localhost:4343",
+	}
+}
+
+// TLS reports whether the dashboard is served over https.
+func (c *Config) TLS() bool {
+	return c.TLSCert != "" || c.SelfSigned
+}
+
+// Loopback reports whether the dashboard only listens on the loopback
+// interface, where the daemon endpoints can't be reached from the network.
+func (c *Config) Loopback() bool {
+	host, _, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// LoadConfig builds the server config from the config file, the environment
+// and args.
+func LoadConfig(args []string) (*Config, error) {
+	// the first pass only finds the config file, whose values become the
+	// flag defaults of the second pass
+	cfg := DefaultConfig()
+	configFile := os.Getenv(envPrefix + "CONFIG")
+	_, err := parseFlags(args, cfg, &configFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg = DefaultConfig()
+	if configFile != "" {
+		buf, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(buf, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", configFile, err.Error())
+		}
+	}
+	err = cfg.applyEnv()
+	if err != nil {
+		return nil, err
+	}
+	fs, err := parseFlags(args, cfg, &configFile)
+	if err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if cfg.SelfSigned {
+		if cfg.TLSCert == "" {
+			cfg.TLSCert = defaultSelfSignedCert
+		}
+		if cfg.TLSKey == "" {
+			cfg.TLSKey = defaultSelfSignedKey
+		}
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return nil, fmt.Errorf("both a tls certificate and key are required")
+	}
+	return cfg, nil
+}
+
+func parseFlags(args []string, cfg *Config, configFile *string) (*flag.FlagSet, error) {
+	fs := flag.NewFlagSet("hive-dashboard", flag.ContinueOnError)
+	fs.StringVar(configFile, "config", *configFile, "JSON config file")
+	fs.StringVar(&cfg.Listen, "listen", cfg.Listen, "address the dashboard listens on, other than localhost it requires -users or -token to proxy the daemon")
+	fs.StringVar(&cfg.Assets, "assets", cfg.Assets, "serve the dashboard from this directory instead of the embedded assets, e.g. ../assets during development")
+	fs.StringVar(&cfg.Upstream, "upstream", cfg.Upstream, "hive daemon to proxy /v3/execute and /v3/events to, empty disables the proxy")
+	fs.BoolVar(&cfg.Mock, "mock", cfg.Mock, "serve a mock hive daemon on /v3/execute and /v3/events")
+	fs.StringVar(&cfg.Users, "users", cfg.Users, "file with username:bcrypt-hash lines allowed to log in")
+	fs.StringVar(&cfg.Token, "token", cfg.Token, "bearer token allowed to log in and call the daemon endpoints")
+	fs.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "tls certificate file, enables https")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "tls private key file")
+	fs.BoolVar(&cfg.SelfSigned, "self-signed", cfg.SelfSigned, "generate a self-signed certificate at -tls-cert and -tls-key if they don't exist")
+	fs.BoolVar(&cfg.HashPassword, "hash-password", false, "read a password from stdin and print its hash for the users file")
+	return fs, fs.Parse(args)
+}
+
+func (c *Config) applyEnv() error {
+	stringVars := map[string]*string{
+		"LISTEN":   &c.Listen,
+		"ASSETS":   &c.Assets,
+		"UPSTREAM": &c.Upstream,
+		"USERS":    &c.Users,
+		"TOKEN":    &c.Token,
+		"TLS_CERT": &c.TLSCert,
+		"TLS_KEY":  &c.TLSKey,
+	}
+	for name, field := range stringVars {
+		if value, found := os.LookupEnv(envPrefix + name); found {
+			*field = value
+		}
+	}
+	boolVars := map[string]*bool{
+		"MOCK":        &c.Mock,
+		"SELF_SIGNED": &c.SelfSigned,
+	}
+	for name, field := range boolVars {
+		value, found := os.LookupEnv(envPrefix + name)
+		if !found {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s%s: %s", envPrefix, name, err.Error())
+		}
+		*field = parsed
+	}
+	return nil
+}
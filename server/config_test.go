@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setEnv sets an environment variable for the duration of the test
+func setEnv(t *testing.T, key, value string) {
+	t.Helper()
+	old, found := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "dashboard.json")
+	err := os.WriteFile(configFile, []byte(`{"listen": ":7000", "upstream": "http://file:4343", "token": "from-file", "mock": true}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		args []string
+		want Config
+	}{
+		{"defaults", nil, nil, Config{Listen: "localhost:9090", Upstream: "http://localhost:4343"}},
+		{"file", nil, []string{"-config", configFile},
+			Config{Listen: ":7000", Upstream: "http://file:4343", Token: "from-file", Mock: true}},
+		{"config file from the environment", map[string]string{"CONFIG": configFile}, nil,
+			Config{Listen: ":7000", Upstream: "http://file:4343", Token: "from-file", Mock: true}},
+		{"environment over file", map[string]string{"CONFIG": configFile, "LISTEN": ":7001", "MOCK": "false"}, nil,
+			Config{Listen: ":7001", Upstream: "http://file:4343", Token: "from-file"}},
+		{"flags over environment", map[string]string{"CONFIG": configFile, "LISTEN": ":7001", "TOKEN": "from-env"}, []string{"-listen", ":7002", "-mock=false"},
+			Config{Listen: ":7002", Upstream: "http://file:4343", Token: "from-env"}},
+		{"self-signed", nil, []string{"-self-signed"},
+			Config{Listen: "localhost:9090", Upstream: "http://localhost:4343", SelfSigned: true, TLSCert: defaultSelfSignedCert, TLSKey: defaultSelfSignedKey}},
+		{"self-signed at a path", map[string]string{"SELF_SIGNED": "true"}, []string{"-tls-cert", "my.crt", "-tls-key", "my.key"},
+			Config{Listen: "localhost:9090", Upstream: "http://localhost:4343", SelfSigned: true, TLSCert: "my.crt", TLSKey: "my.key"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				setEnv(t, envPrefix+key, value)
+			}
+			cfg, err := LoadConfig(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *cfg != tc.want {
+				t.Errorf("got %+v, want %+v", *cfg, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigLoopback(t *testing.T) {
+	for listen, want := range map[string]bool{
+		"localhost:9090": true,
+		"127.0.0.1:9090": true,
+		"[::1]:9090":     true,
+		":9090":          false,
+		"0.0.0.0:9090":   false,
+		"[::]:9090":      false,
+		"192.0.2.1:9090": false,
+		"example.com:80": false,
+		"localhost":      false,
+	} {
+		cfg := Config{Listen: listen}
+		if got := cfg.Loopback(); got != want {
+			t.Errorf("%s: got %v, want %v", listen, got, want)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"listen": 9090}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"missing config file", nil, []string{"-config", filepath.Join(dir, "missing.json")}},
+		{"invalid config file", nil, []string{"-config", invalid}},
+		{"invalid bool", map[string]string{"MOCK": "sometimes"}, nil},
+		{"unknown flag", nil, []string{"-verbose"}},
+		{"extra arguments", nil, []string{"serve"}},
+		{"certificate without key", nil, []string{"-tls-cert", "my.crt"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for key, value := range tc.env {
+				setEnv(t, envPrefix+key, value)
+			}
+			if _, err := LoadConfig(tc.args); err == nil {
+				t.Error("got no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,423 @@
+//go:build e2e
+// +build e2e
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The end-to-end tests run the built hive.wasm under Node with the DOM shim
+// of e2e/harness.js, against the mock daemon:
+//
+//	go test -tags e2e .
+//
+// HIVE_WASM_EXEC selects the wasm_exec.js loaded by the harness, it defaults
+// to the one in assets and has to match the Go release running the tests.
+
+type pageSnapshot struct {
+	Ready struct {
+		Page   string            `json:"page"`
+		Errors map[string]string `json:"errors"`
+	} `json:"ready"`
+	Elements  map[string]pageElement `json:"elements"`
+	Storage   map[string]string      `json:"storage"`
+	PageCalls []struct {
+		Name string        `json:"name"`
+		Args []interface{} `json:"args"`
+	} `json:"pageCalls"`
+	Downloads []struct {
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	} `json:"downloads"`
+}
+
+type pageElement struct {
+	Tag         string        `json:"tag"`
+	TextContent string        `json:"textContent"`
+	InnerHTML   string        `json:"innerHTML"`
+	Value       string        `json:"value"`
+	Placeholder string        `json:"placeholder"`
+	ClassName   string        `json:"className"`
+	Style       string        `json:"style"`
+	Children    []pageElement `json:"children"`
+}
+
+var (
+	wasmOnce sync.Once
+	wasmDir  string
+	wasmErr  error
+)
+
+// buildWasm builds hive.wasm once for every test
+func buildWasm(t *testing.T) string {
+	t.Helper()
+	wasmOnce.Do(func() {
+		wasmDir, wasmErr = ioutil.TempDir("", "hive-e2e")
+		if wasmErr != nil {
+			return
+		}
+		cmd := exec.Command("go", "build", "-o", filepath.Join(wasmDir, "hive.wasm"), ".")
+		cmd.Dir = filepath.Join("..", "wasm")
+		cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			wasmErr = &buildError{err: err, out: string(out)}
+		}
+	})
+	if wasmErr != nil {
+		t.Fatalf("Failed to build hive.wasm: %s", wasmErr.Error())
+	}
+	return filepath.Join(wasmDir, "hive.wasm")
+}
+
+type buildError struct {
+	err error
+	out string
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error() + "\n" + e.out
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if wasmDir != "" {
+		os.RemoveAll(wasmDir)
+	}
+	os.Exit(code)
+}
+
+// wasmExec returns the wasm_exec.js the harness loads, failing when the one
+// in assets doesn't belong to the Go release building hive.wasm.
+func wasmExec(t *testing.T) string {
+	t.Helper()
+	if path := os.Getenv("HIVE_WASM_EXEC"); path != "" {
+		return path
+	}
+	path := filepath.Join("..", "assets", "wasm_exec.js")
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		t.Fatalf("Failed to find GOROOT: %s", err.Error())
+	}
+	goroot := strings.TrimSpace(string(out))
+	assets, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, release := range []string{"lib/wasm/wasm_exec.js", "misc/wasm/wasm_exec.js"} {
+		buf, err := ioutil.ReadFile(filepath.Join(goroot, release))
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(buf, assets) {
+			t.Fatalf("%s doesn't match %s, copy it to assets or set HIVE_WASM_EXEC", path, filepath.Join(goroot, release))
+		}
+		break
+	}
+	return path
+}
+
+// runPage serves the assets and the mock daemon and runs page under the
+// harness with the steps.
+func runPage(t *testing.T, daemon *MockDaemon, page, start string, steps ...string) *pageSnapshot {
+	t.Helper()
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Fatal("node is required by the end-to-end tests")
+	}
+	wasm := buildWasm(t)
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(Assets(filepath.Join("..", "assets")))))
+	api := http.NewServeMux()
+	daemon.Register(api)
+	mux.Handle("/v3/", api)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	args := []string{
+		filepath.Join("..", "e2e", "harness.js"),
+		"--wasm", wasm,
+		"--wasm-exec", wasmExec(t),
+		"--page", filepath.Join("..", "assets", page),
+		"--url", srv.URL + "/" + page,
+	}
+	if start != "" {
+		args = append(args, "--start", start)
+	}
+	cmd := exec.Command("node", append(args, steps...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("harness failed: %s\n%s", err.Error(), stderr.String())
+	}
+	var snapshot pageSnapshot
+	err = json.Unmarshal(out, &snapshot)
+	if err != nil {
+		t.Fatalf("invalid harness output: %s\n%s", err.Error(), out)
+	}
+	return &snapshot
+}
+
+func (s *pageSnapshot) element(t *testing.T, id string) pageElement {
+	t.Helper()
+	element, found := s.Elements[id]
+	if !found {
+		t.Fatalf("no element #%s on the page", id)
+	}
+	return element
+}
+
+func childText(element pageElement) []string {
+	var text []string
+	for _, child := range element.Children {
+		text = append(text, child.InnerHTML)
+	}
+	return text
+}
+
+func TestE2EDashboard(t *testing.T) {
+	daemon := NewMockDaemon()
+	page := runPage(t, daemon, "index.html", "",
+		"--until", "PeerID",
+		"--until", "confirmedBalance",
+		"--until", "taskmanagerstatusname",
+		"--until", "Pending",
+		"--until", "PeerTable",
+		"--call", "GetEarning",
+	)
+	if page.Ready.Page != "dashboard" || len(page.Ready.Errors) != 0 {
+		t.Errorf("Start: got page %q errors %v", page.Ready.Page, page.Ready.Errors)
+	}
+	if got := page.element(t, "PeerID").TextContent; got != daemon.peerID {
+		t.Errorf("PeerID: got %q, want %q", got, daemon.peerID)
+	}
+	if got := page.element(t, "Version").TextContent; got != "v0.2.14-mock" {
+		t.Errorf("Version: got %q", got)
+	}
+	balance := regexp.MustCompile(`^\d+(\.\d{1,4})? SWRM$`)
+	if got := page.element(t, "confirmedBalance").TextContent; !balance.MatchString(got) {
+		t.Errorf("confirmedBalance: got %q", got)
+	}
+	earned := regexp.MustCompile(`^\d+\.\d{8} SWRM$`)
+	if got := page.element(t, "EarnedCycle").InnerHTML; !earned.MatchString(got) {
+		t.Errorf("EarnedCycle: got %q", got)
+	}
+	if got := childText(page.element(t, "taskmanagerstatusname")); !reflect.DeepEqual(got, []string{"Replication", "GC"}) {
+		t.Errorf("task names: got %q", got)
+	}
+	if got := childText(page.element(t, "taskmanagerstatusstatus")); !reflect.DeepEqual(got, []string{"Running", "Waiting"}) {
+		t.Errorf("task status: got %q", got)
+	}
+	if got := page.element(t, "ConnectionState").InnerHTML; got != "LIVE" {
+		t.Errorf("ConnectionState: got %q", got)
+	}
+	if got := page.element(t, "Proxy").TextContent; got != "Not Running" {
+		t.Errorf("Proxy: got %q", got)
+	}
+	if got := page.element(t, "PeerSummary").TextContent; !regexp.MustCompile(`^\d+ peers, \d+ connections: `).MatchString(got) {
+		t.Errorf("PeerSummary: got %q", got)
+	}
+	if got := page.element(t, "PeerChurn").TextContent; !regexp.MustCompile(`^Churn \d+\.\d/min: \d+ joined, \d+ left in 10 minutes$`).MatchString(got) {
+		t.Errorf("PeerChurn: got %q", got)
+	}
+	if got := childText(page.element(t, "Address")); len(got) == 0 || !strings.HasPrefix(got[0], "IPv4 tcp ") {
+		t.Errorf("Address: got %q", got)
+	}
+	graphed := false
+	for _, call := range page.PageCalls {
+		graphed = graphed || call.Name == "CreateGraph"
+	}
+	if !graphed {
+		t.Error("the earnings graph wasn't drawn")
+	}
+}
+
+func TestE2EBandwidthHistory(t *testing.T) {
+	daemon := NewMockDaemon()
+	page := runPage(t, daemon, "index.html", "",
+		"--until", "Incoming",
+		"--call", "ToggleBandwidthHistory",
+		"--until", "BandwidthSamples",
+	)
+	if got := page.element(t, "BandwidthPanel").Style; !strings.Contains(got, "display: block") {
+		t.Errorf("BandwidthPanel: got style %q", got)
+	}
+	rate := regexp.MustCompile(`^-?\d+\.\d [KMGT]?B/s$`)
+	for _, id := range []string{"BandwidthInMin", "BandwidthInAvg", "BandwidthInMax", "BandwidthInP95", "BandwidthOutP95"} {
+		if got := page.element(t, id).TextContent; !rate.MatchString(got) {
+			t.Errorf("%s: got %q", id, got)
+		}
+	}
+	if got := page.element(t, "BandwidthSamples").TextContent; !regexp.MustCompile(`^[1-9]\d* samples$`).MatchString(got) {
+		t.Errorf("BandwidthSamples: got %q", got)
+	}
+	drawn := false
+	for _, call := range page.PageCalls {
+		drawn = drawn || call.Name == "DrawBandwidthChart"
+	}
+	if !drawn {
+		t.Error("the bandwidth chart wasn't drawn")
+	}
+}
+
+func TestE2EHistoryPersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hive-e2e-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	saved := filepath.Join(dir, "indexeddb.json")
+	daemon := NewMockDaemon()
+	// the second run has to poll the bandwidth at a later second than the first
+	runPage(t, daemon, "index.html", "",
+		"--indexeddb", saved,
+		"--until", "Incoming",
+		"--until", "confirmedBalance",
+		"--wait", "1000",
+	)
+	var databases map[string]struct {
+		Stores map[string]struct {
+			Records []struct {
+				Namespace string `json:"namespace"`
+				Value     string `json:"value"`
+			} `json:"records"`
+		} `json:"stores"`
+	}
+	buf, err := ioutil.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(buf, &databases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespaces := make(map[string]int)
+	for _, record := range databases["hive-history"].Stores["items"].Records {
+		namespaces[record.Namespace]++
+	}
+	for _, namespace := range []string{"Bandwidth", "UserBalance", "UptimePercentage", "Event"} {
+		if namespaces[namespace] == 0 {
+			t.Errorf("no %s snapshots were saved, got %v", namespace, namespaces)
+		}
+	}
+
+	page := runPage(t, daemon, "index.html", "",
+		"--indexeddb", saved,
+		"--until", "Incoming",
+		"--call", "ToggleBandwidthHistory",
+		"--until", "BandwidthSamples",
+	)
+	samples := regexp.MustCompile(`^(\d+) samples$`).FindStringSubmatch(page.element(t, "BandwidthSamples").TextContent)
+	if samples == nil || samples[1] == "0" || samples[1] == "1" {
+		t.Errorf("BandwidthSamples: got %q, want the samples of both runs", page.element(t, "BandwidthSamples").TextContent)
+	}
+}
+
+func TestE2EExportEarnings(t *testing.T) {
+	daemon := NewMockDaemon()
+	page := runPage(t, daemon, "index.html", "",
+		"--until", "ExportFrom",
+		"--call", "ExportEarnings",
+	)
+	if len(page.Downloads) != 1 {
+		t.Fatalf("got %d downloads", len(page.Downloads))
+	}
+	download := page.Downloads[0]
+	if download.Name != "hive-earnings_all-devices.csv" || download.Type != "text/csv" {
+		t.Errorf("got %s %s", download.Name, download.Type)
+	}
+	lines := strings.Split(strings.TrimSpace(download.Content), "\n")
+	if lines[0] != "cycle,device,peer_id,earned_swrm,served_bytes,downloaded_bytes" {
+		t.Errorf("header: got %q", lines[0])
+	}
+	cycles := len(page.element(t, "ExportFrom").Children)
+	if want := 1 + cycles*len(daemon.devices); cycles == 0 || len(lines) != want {
+		t.Errorf("got %d lines for %d cycles, want %d", len(lines), cycles, want)
+	}
+}
+
+func TestE2ESwarmPort(t *testing.T) {
+	for _, tc := range []struct {
+		port string
+		want string
+	}{
+		{"", "Enter A Valid Port Number"},
+		{"80", "Port 80 is Unavailable"},
+		{"4o01", "Port 4o01 is Not a Number"},
+		{"4005", "SwrmPort Changed to 4005"},
+	} {
+		t.Run(tc.port, func(t *testing.T) {
+			daemon := NewMockDaemon()
+			page := runPage(t, daemon, "Settings.html", "settings",
+				"--set", "SwrmPortNumber.value="+tc.port,
+				"--call", "SetSwrmPortNumber",
+				"--until", "SwrmPortStatus",
+			)
+			if page.Ready.Page != "settings" || len(page.Ready.Errors) != 0 {
+				t.Errorf("Start: got page %q errors %v", page.Ready.Page, page.Ready.Errors)
+			}
+			if got := page.element(t, "SwrmPortStatus").InnerHTML; got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			daemon.mtx.Lock()
+			port := daemon.config["SwarmPort"]
+			daemon.mtx.Unlock()
+			if changed := port == tc.port; changed != strings.HasPrefix(tc.want, "SwrmPort Changed") {
+				t.Errorf("daemon SwarmPort is %v", port)
+			}
+		})
+	}
+}
+
+func TestE2EPeerActions(t *testing.T) {
+	const peer = "12D3KooWE2EDialedPeerXYZ"
+	addr := "/ip4/198.51.100.77/tcp/4001/p2p/" + peer
+	daemon := NewMockDaemon()
+	page := runPage(t, daemon, "index.html", "",
+		"--until", "PeerTable",
+		"--set", "PeerDialAddr.value="+addr,
+		"--call", "DialPeer",
+		"--until", "PeerActionStatus",
+		"--call", "PeerAction:ping,"+peer,
+		"--wait", "1000",
+	)
+	if got := page.element(t, "PeerActionStatus").TextContent; !regexp.MustCompile(`^ping 12D3KooW…eerXYZ: \d+\.\d ms$`).MatchString(got) {
+		t.Errorf("PeerActionStatus after ping: got %q", got)
+	}
+	daemon.mtx.Lock()
+	dialed := daemon.dialed[addr]
+	daemon.mtx.Unlock()
+	if !dialed {
+		t.Fatalf("the daemon didn't connect to %s", addr)
+	}
+
+	page = runPage(t, daemon, "index.html", "",
+		"--until", "PeerTable",
+		"--call", "PeerAction:disconnect,"+peer,
+		"--wait", "1000",
+	)
+	if got := page.element(t, "PeerActionStatus").TextContent; got != "disconnect 12D3KooW…eerXYZ: disconnected" {
+		t.Errorf("PeerActionStatus after disconnect: got %q", got)
+	}
+	daemon.mtx.Lock()
+	defer daemon.mtx.Unlock()
+	for _, p := range daemon.peers {
+		if p == addr {
+			t.Errorf("the daemon is still connected to %s", addr)
+		}
+	}
+}
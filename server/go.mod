@@ -0,0 +1,10 @@
+module github.com/StreamSpace/hive-wasm-client/server
+
+go 1.16
+
+require (
+	github.com/StreamSpace/hive-wasm-client/assets v0.0.0
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+)
+
+replace github.com/StreamSpace/hive-wasm-client/assets => ../assets
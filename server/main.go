@@ -1,15 +1,94 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 )
 
 func main() {
-	fmt.Println("Running DashBoard on Port 9090")
-	err := http.ListenAndServe(":9090", http.FileServer(http.Dir("../assets")))
+	cfg, err := LoadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
 	if err != nil {
-		fmt.Println("Failed to start server", err)
+		fmt.Println("Failed to load config", err)
+		os.Exit(2)
+	}
+
+	if cfg.HashPassword {
+		password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && password == "" {
+			fmt.Println("Failed to read password", err)
+			os.Exit(1)
+		}
+		hash, err := HashPassword(strings.TrimRight(password, "\r\n"))
+		if err != nil {
+			fmt.Println("Failed to hash password", err)
+			os.Exit(1)
+		}
+		fmt.Println(hash)
 		return
 	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(Assets(cfg.Assets))))
+	daemon := http.NewServeMux()
+	switch {
+	case cfg.Mock:
+		fmt.Println("Serving mock hive daemon on /v3/execute and /v3/events")
+		NewMockDaemon().Register(daemon)
+	case cfg.Upstream != "":
+		if cfg.Users == "" && cfg.Token == "" && !cfg.Loopback() {
+			fmt.Println("Refusing to proxy the hive daemon on", cfg.Listen, "without a login, set --users or --token or listen on localhost")
+			os.Exit(1)
+		}
+		err := RegisterProxy(daemon, cfg.Upstream)
+		if err != nil {
+			fmt.Println("Failed to start daemon proxy", err)
+			os.Exit(1)
+		}
+		fmt.Println("Proxying hive daemon at", cfg.Upstream)
+	}
+	if cfg.Users != "" || cfg.Token != "" {
+		auth, err := NewAuth(cfg.Users, cfg.Token)
+		if err != nil {
+			fmt.Println("Failed to load users", err)
+			os.Exit(1)
+		}
+		auth.SetSecure(cfg.TLS())
+		auth.Register(mux)
+		mux.Handle("/v3/", auth.Protect(daemon))
+		fmt.Println("Login required for the daemon endpoints")
+	} else {
+		mux.Handle("/v3/", daemon)
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if cfg.TLS() {
+		if cfg.SelfSigned {
+			err = EnsureSelfSignedCert(cfg.TLSCert, cfg.TLSKey)
+			if err != nil {
+				fmt.Println("Failed to generate self-signed certificate", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Println("Running DashBoard on https", cfg.Listen)
+		err = server.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+	} else {
+		fmt.Println("Running DashBoard on http", cfg.Listen)
+		err = server.ListenAndServe()
+	}
+	if err != nil {
+		fmt.Println("Failed to start server", err)
+		os.Exit(1)
+	}
 }
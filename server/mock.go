@@ -0,0 +1,541 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	splicer       = "%$#"
+	statusOK      = 200
+	statusInvalid = 400
+)
+
+// mockTopics lists the events streamed by the mock daemon and how often
+// each of them is published.
+var mockTopics = []struct {
+	Topic    string
+	Interval time.Duration
+}{
+	{"Status", 5 * time.Second},
+	{"Balance", 10 * time.Second},
+	{"BalanceCycle", 10 * time.Second},
+	{"Peers", 15 * time.Second},
+	{"Settlement", 30 * time.Second},
+	{"Settings", 30 * time.Second},
+}
+
+// MockDaemon implements the execute and events endpoints of the hive daemon
+// with canned and randomized payloads, so the dashboard can run without one.
+type MockDaemon struct {
+	mtx       sync.Mutex
+	random    *rand.Rand
+	startTime time.Time
+	peerID    string
+	devices   []map[string]interface{}
+	config    map[string]interface{}
+	settings  map[string]interface{}
+	peers     []string
+	dialed    map[string]bool
+	balance   float64
+	owned     float64
+	served    float64
+	download  float64
+}
+
+func NewMockDaemon() *MockDaemon {
+	m := &MockDaemon{
+		random:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		startTime: time.Now(),
+		dialed:    make(map[string]bool),
+	}
+	m.peerID = m.randomPeerID()
+	m.devices = []map[string]interface{}{
+		{"name": "hive-desktop", "peerId": m.peerID},
+		{"name": "hive-nas", "peerId": m.randomPeerID()},
+	}
+	m.config = map[string]interface{}{
+		"APIPort":                        "4343",
+		"AutoGC":                         true,
+		"Bootstraps":                     []string{"/dns4/bootstrap.swarm.city/tcp/4001/p2p/" + m.randomPeerID()},
+		"DNS4":                           "",
+		"DataStore":                      "leveldb",
+		"DesktopApplicationAutoStart":    true,
+		"DesktopApplicationNotification": true,
+		"DeviceName":                     "hive-desktop",
+		"EnableDynamicDNS":               false,
+		"EnableFileShare":                true,
+		"EnableHop":                      false,
+		"GCPeriod":                       "1h",
+		"GatewayPort":                    "5002",
+		"IP4":                            "0.0.0.0",
+		"IP6":                            "::",
+		"MaxPeers":                       50,
+		"ProxyPort":                      "5001",
+		"ReproviderInterval":             "12h",
+		"Storage":                        25.0,
+		"StorageGCWatermark":             90,
+		"Store":                          "/home/hive/.hive/store",
+		"SwarmPort":                      "4001",
+		"WebsocketPort":                  "4002",
+	}
+	m.settings = map[string]interface{}{
+		"nodeIndex":          1.0,
+		"deviceId":           "5f8d0d55b54764421b7156c3",
+		"name":               "hive-desktop",
+		"location":           "Bengaluru",
+		"ipAddress":          "203.0.113.24",
+		"maxStorage":         25.0,
+		"usedStorage":        3.42,
+		"pinned_storage":     0.5,
+		"hive_storage":       2.92,
+		"peerId":             m.peerID,
+		"publicKey":          "CAESIBexngmQaajsIsRRDp1CwmEGCt1rYVZq61zsIGhTlfgt",
+		"isReachable":        true,
+		"isDnsEligible":      true,
+		"isOSNotification":   true,
+		"isAutoStartEnabled": true,
+		"dnsAddress":         "hive-desktop.swarm.city",
+		"role":               "Miner",
+		"freeDiskSpace":      float64(180 << 30),
+	}
+	for i := 0; i < 8; i++ {
+		m.peers = append(m.peers, m.randomMultiaddr())
+	}
+	m.balance = 120 + m.random.Float64()*10
+	return m
+}
+
+func (m *MockDaemon) randomPeerID() string {
+	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	id := []byte("12D3KooW")
+	for len(id) < 52 {
+		id = append(id, alphabet[m.random.Intn(len(alphabet))])
+	}
+	return string(id)
+}
+
+func (m *MockDaemon) randomMultiaddr() string {
+	ip := fmt.Sprintf("%d.%d.%d.%d", 1+m.random.Intn(223), m.random.Intn(256), m.random.Intn(256), 1+m.random.Intn(254))
+	port := 1025 + m.random.Intn(48000)
+	switch m.random.Intn(4) {
+	case 0:
+		return fmt.Sprintf("/ip4/%s/udp/%d/quic/p2p/%s", ip, port, m.randomPeerID())
+	case 1:
+		return fmt.Sprintf("/ip4/%s/tcp/%d/ws/p2p/%s", ip, port, m.randomPeerID())
+	case 2:
+		return fmt.Sprintf("/ip6/2001:db8::%x/tcp/%d/p2p/%s", m.random.Intn(0xffff), port, m.randomPeerID())
+	default:
+		return fmt.Sprintf("/ip4/%s/tcp/%d/p2p/%s", ip, port, m.randomPeerID())
+	}
+}
+
+// Register mounts the mock daemon endpoints on mux.
+func (m *MockDaemon) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/v3/execute", m.Execute)
+	mux.HandleFunc("/v3/events", m.Events)
+}
+
+// Execute runs a hive-cli command sent by the dashboard.
+func (m *MockDaemon) Execute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload := make(map[string]string)
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	args := strings.Split(payload["val"], splicer)
+	if len(args) > 0 {
+		// the first argument is the hive-cli binary
+		args = args[1:]
+	}
+	m.mtx.Lock()
+	val, err := json.Marshal(m.run(args))
+	m.mtx.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"val": string(val)})
+}
+
+// run executes a command, the caller must hold m.mtx until the result is encoded.
+func (m *MockDaemon) run(args []string) map[string]interface{} {
+	command := strings.Join(withoutFlag(args, "-j"), " ")
+	switch {
+	case command == "id":
+		return ok("", map[string]interface{}{
+			"id":        m.peerID,
+			"PublicKey": m.settings["publicKey"],
+			"Addresses": []string{
+				"/ip4/127.0.0.1/tcp/" + m.config["SwarmPort"].(string),
+				"/ip4/203.0.113.24/tcp/" + m.config["SwarmPort"].(string),
+				"/ip4/203.0.113.24/tcp/" + m.config["WebsocketPort"].(string) + "/ws",
+				"/ip6/::1/tcp/" + m.config["SwarmPort"].(string),
+			},
+		})
+	case command == "status":
+		return ok("", m.status())
+	case command == "config show":
+		return ok("", m.config)
+	case command == "config get-storage-location":
+		return ok("", m.config["Store"])
+	case strings.HasPrefix(command, "config modify"):
+		return m.modifyConfig(args)
+	case command == "settings -g":
+		return ok("", m.settings)
+	case command == "settings":
+		return ok("Settings saved", nil)
+	case command == "earning -g":
+		return ok("", m.earnings())
+	case command == "stat bandwidth":
+		return ok("", map[string]interface{}{
+			"RateIn":  m.random.Float64() * (4 << 20),
+			"RateOut": m.random.Float64() * (2 << 20),
+			"Time":    time.Now().Unix(),
+		})
+	case command == "swarm peers":
+		m.churnPeers()
+		return ok("", m.peers)
+	case strings.HasPrefix(command, "swarm connect"):
+		return m.swarmConnect(withoutFlag(args, "-j"))
+	case strings.HasPrefix(command, "swarm disconnect"):
+		return m.swarmDisconnect(withoutFlag(args, "-j"))
+	case strings.HasPrefix(command, "ping"):
+		return m.ping(withoutFlag(args, "-j"))
+	case command == "profile":
+		return ok("", map[string]interface{}{
+			"_id":             "5f8d0d55b54764421b7156c2",
+			"email":           "operator@example.com",
+			"firstName":       "Hive",
+			"role":            "Miner",
+			"isEmailVerified": true,
+			"lastLoginAt":     m.startTime.Format(time.RFC3339),
+		})
+	case command == "version":
+		return ok("", map[string]interface{}{
+			"appversion":    "v0.2.14-mock",
+			"currentcommit": "mock",
+			"environment":   "development",
+			"epoch":         "1609459200",
+			"cycleduration": "24h",
+			"platform":      runtime.GOOS,
+		})
+	case command == "verify-port-forward":
+		if m.random.Intn(4) == 0 {
+			return ok("", "Port is NOT forwarded")
+		}
+		return ok("", "Port is forwarded")
+	}
+	return fail(statusInvalid, "unknown command", command)
+}
+
+func (m *MockDaemon) modifyConfig(args []string) map[string]interface{} {
+	if len(args) != 4 {
+		return fail(statusInvalid, "invalid arguments", "usage: config modify <key> <value>")
+	}
+	key, value := args[2], args[3]
+	current, found := m.config[key]
+	if !found {
+		return fail(statusInvalid, "unknown config key", key)
+	}
+	switch current.(type) {
+	case string:
+		if strings.HasSuffix(key, "Port") {
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1025 || port > 49150 {
+				return fail(statusInvalid, fmt.Sprintf("port %s is not available", value), "")
+			}
+		}
+		m.config[key] = value
+	case float64:
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fail(statusInvalid, "invalid value", err.Error())
+		}
+		m.config[key] = size
+		if key == "Storage" {
+			m.settings["maxStorage"] = size
+		}
+	default:
+		return fail(statusInvalid, "config key is read only", key)
+	}
+	return ok(fmt.Sprintf("%s modified", key), nil)
+}
+
+// multiaddrPeer returns the peer ID an address ends with
+func multiaddrPeer(addr string) string {
+	idx := strings.LastIndex(addr, "/p2p/")
+	if idx < 0 {
+		return ""
+	}
+	return addr[idx+len("/p2p/"):]
+}
+
+func (m *MockDaemon) swarmConnect(args []string) map[string]interface{} {
+	if len(args) != 3 {
+		return fail(statusInvalid, "invalid arguments", "usage: swarm connect <multiaddr>")
+	}
+	addr := args[2]
+	peer := multiaddrPeer(addr)
+	if !strings.HasPrefix(addr, "/") || peer == "" || strings.Contains(peer, "/") {
+		return fail(statusInvalid, "invalid multiaddr", "the address must end with /p2p/<peer ID>")
+	}
+	connected := false
+	for _, p := range m.peers {
+		connected = connected || p == addr
+	}
+	if !connected {
+		m.peers = append(m.peers, addr)
+	}
+	m.dialed[addr] = true
+	message := fmt.Sprintf("connect %s success", peer)
+	return ok(message, []string{message})
+}
+
+// swarmDisconnect closes the connection over an address, or every connection
+// to the peer of a /p2p/<peer ID> address
+func (m *MockDaemon) swarmDisconnect(args []string) map[string]interface{} {
+	if len(args) != 3 {
+		return fail(statusInvalid, "invalid arguments", "usage: swarm disconnect <multiaddr>")
+	}
+	addr := args[2]
+	peer := multiaddrPeer(addr)
+	var kept []string
+	for _, p := range m.peers {
+		if p == addr || (strings.HasPrefix(addr, "/p2p/") && multiaddrPeer(p) == peer) {
+			delete(m.dialed, p)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	if len(kept) == len(m.peers) {
+		return fail(statusInvalid, "not connected", addr)
+	}
+	m.peers = kept
+	message := fmt.Sprintf("disconnect %s success", peer)
+	return ok(message, []string{message})
+}
+
+func (m *MockDaemon) ping(args []string) map[string]interface{} {
+	if len(args) != 2 {
+		return fail(statusInvalid, "invalid arguments", "usage: ping <peer ID>")
+	}
+	for _, p := range m.peers {
+		if multiaddrPeer(p) == args[1] {
+			return ok("", map[string]interface{}{
+				"success": true,
+				"time":    time.Duration(5+m.random.Intn(200)) * time.Millisecond,
+			})
+		}
+	}
+	return ok("", map[string]interface{}{
+		"success": false,
+		"text":    "peer " + args[1] + " is not connected",
+	})
+}
+
+func (m *MockDaemon) status() map[string]interface{} {
+	uptime := time.Since(m.startTime)
+	return map[string]interface{}{
+		"LoggedIn":      true,
+		"DaemonRunning": true,
+		"TotalUptimePercentage": map[string]interface{}{
+			"Status":               true,
+			"Percentage":           97 + m.random.Float64()*3,
+			"SecondsFromInception": int64(uptime.Seconds()) + 86400*30,
+			"Timestamp":            time.Now().Unix(),
+		},
+		"SessionStartTime": m.startTime.Unix(),
+		"TaskManagerStatus": []map[string]interface{}{
+			{"Id": 1, "Name": "Idle", "Status": "Running", "AdditionalStatus": ""},
+			{"Id": 2, "Name": "Replication", "Status": "Running", "AdditionalStatus": fmt.Sprintf("%d%%", m.random.Intn(100))},
+			{"Id": 3, "Name": "GC", "Status": "Waiting", "AdditionalStatus": ""},
+		},
+		"ServerStatus": map[string]interface{}{
+			"Rpc":   "Running",
+			"Http":  "Running",
+			"Proxy": "",
+		},
+	}
+}
+
+func (m *MockDaemon) earnings() map[string]interface{} {
+	var cycles []string
+	for i := 0; i < 12; i++ {
+		cycles = append(cycles, time.Now().AddDate(0, 0, -i).Format("02-01-2006"))
+	}
+	earnings := make(map[string]interface{})
+	for _, device := range m.devices {
+		var list []map[string]interface{}
+		for range cycles {
+			list = append(list, map[string]interface{}{
+				"earned":   m.random.Float64() * 2,
+				"served":   m.random.Float64() * (8 << 30),
+				"download": m.random.Float64() * (1 << 30),
+			})
+		}
+		earnings[device["peerId"].(string)] = list
+	}
+	return map[string]interface{}{
+		"billingCycles": cycles,
+		"devices":       m.devices,
+		"earnings":      earnings,
+	}
+}
+
+// churnPeers drops and adds a few peers so the peer list changes over time.
+func (m *MockDaemon) churnPeers() {
+	for i := 0; i < m.random.Intn(3) && len(m.peers) > 3; i++ {
+		idx := m.random.Intn(len(m.peers))
+		if m.dialed[m.peers[idx]] {
+			// peers connected by swarm connect stay until disconnected
+			continue
+		}
+		m.peers = append(m.peers[:idx], m.peers[idx+1:]...)
+	}
+	for i := 0; i < m.random.Intn(3); i++ {
+		m.peers = append(m.peers, m.randomMultiaddr())
+	}
+}
+
+// topic returns the data published on an event topic, the caller must hold
+// m.mtx until the result is encoded.
+func (m *MockDaemon) topic(topic string) interface{} {
+	switch topic {
+	case "Status":
+		return m.status()
+	case "Balance":
+		m.balance += m.random.Float64() / 100
+		return m.balance
+	case "BalanceCycle":
+		m.owned += m.random.Float64() / 50
+		m.served += m.random.Float64() * (64 << 20)
+		m.download += m.random.Float64() * (8 << 20)
+		return map[string]interface{}{
+			"owned":      m.owned,
+			"owe":        m.owned / 10,
+			"served":     m.served,
+			"downloaded": m.download,
+			"id":         m.peerID,
+		}
+	case "Peers":
+		m.churnPeers()
+		return len(m.peers)
+	case "Settlement":
+		next := time.Now().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return map[string]interface{}{
+			"bcn":             time.Now().Unix() / 86400,
+			"settlementDate":  next,
+			"dataRatePerByte": 0.000000000125,
+		}
+	case "Settings":
+		return m.settings
+	}
+	return nil
+}
+
+// Events streams newline delimited topic events until the client goes away.
+func (m *MockDaemon) Events(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, canFlush := w.(http.Flusher)
+	if !canFlush {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	send := func(topic string) error {
+		m.mtx.Lock()
+		out, err := json.Marshal(okOut(m.topic(topic)))
+		m.mtx.Unlock()
+		if err != nil {
+			return err
+		}
+		event := map[string]interface{}{
+			"result": map[string]string{
+				"topic": topic,
+				"val":   string(out),
+			},
+		}
+		err = json.NewEncoder(w).Encode(event)
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	for _, t := range mockTopics {
+		if send(t.Topic) != nil {
+			return
+		}
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	elapsed := time.Duration(0)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			elapsed += time.Second
+			for _, t := range mockTopics {
+				if elapsed%t.Interval != 0 {
+					continue
+				}
+				if send(t.Topic) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func okOut(data interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"status":  statusOK,
+		"message": "success",
+		"data":    data,
+	}
+}
+
+func ok(message string, data interface{}) map[string]interface{} {
+	out := okOut(data)
+	if message != "" {
+		out["message"] = message
+	}
+	return out
+}
+
+func fail(status int, message, details string) map[string]interface{} {
+	return map[string]interface{}{
+		"status":  status,
+		"message": message,
+		"details": details,
+	}
+}
+
+func withoutFlag(args []string, flag string) []string {
+	var filtered []string
+	for _, arg := range args {
+		if arg != flag {
+			filtered = append(filtered, arg)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// execute sends a hive-cli command to the mock daemon and returns its output
+func execute(t *testing.T, m *MockDaemon, args ...string) map[string]interface{} {
+	t.Helper()
+	buf, err := json.Marshal(map[string]string{"val": strings.Join(append([]string{"hive-cli"}, args...), splicer)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	m.Execute(w, httptest.NewRequest(http.MethodPost, "/v3/execute", strings.NewReader(string(buf))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%v: got status %d", args, w.Code)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(resp["val"]), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestMockExecute(t *testing.T) {
+	m := NewMockDaemon()
+	peer := "/ip4/198.51.100.7/tcp/4001/p2p/QmPeerA"
+	for _, tc := range []struct {
+		args    []string
+		status  float64
+		message string
+	}{
+		{[]string{"id", "-j"}, statusOK, "success"},
+		{[]string{"status", "-j"}, statusOK, "success"},
+		{[]string{"config", "show", "-j"}, statusOK, "success"},
+		{[]string{"version", "-j"}, statusOK, "success"},
+		{[]string{"config", "modify", "SwarmPort", "4010"}, statusOK, "SwarmPort modified"},
+		{[]string{"config", "modify", "SwarmPort", "80"}, statusInvalid, "port 80 is not available"},
+		{[]string{"config", "modify", "Storage", "many"}, statusInvalid, "invalid value"},
+		{[]string{"config", "modify", "AutoGC", "false"}, statusInvalid, "config key is read only"},
+		{[]string{"config", "modify", "Unknown", "1"}, statusInvalid, "unknown config key"},
+		{[]string{"config", "modify"}, statusInvalid, "invalid arguments"},
+		{[]string{"swarm", "connect", "/ip4/198.51.100.7/tcp/4001", "-j"}, statusInvalid, "invalid multiaddr"},
+		{[]string{"swarm", "connect", peer, "-j"}, statusOK, "connect QmPeerA success"},
+		{[]string{"swarm", "disconnect", peer, "-j"}, statusOK, "disconnect QmPeerA success"},
+		{[]string{"swarm", "disconnect", peer, "-j"}, statusInvalid, "not connected"},
+		{[]string{"ping", "-j"}, statusInvalid, "invalid arguments"},
+		{[]string{"rm", "-rf", "/"}, statusInvalid, "unknown command"},
+	} {
+		out := execute(t, m, tc.args...)
+		if out["status"] != tc.status || out["message"] != tc.message {
+			t.Errorf("%v: got %v %q, want %v %q", tc.args, out["status"], out["message"], tc.status, tc.message)
+		}
+	}
+	if got := m.config["SwarmPort"]; got != "4010" {
+		t.Errorf("SwarmPort: got %v", got)
+	}
+}
+
+func TestMockPing(t *testing.T) {
+	m := NewMockDaemon()
+	execute(t, m, "swarm", "connect", "/ip4/198.51.100.7/tcp/4001/p2p/QmPeerA", "-j")
+	for _, tc := range []struct {
+		peer    string
+		success bool
+	}{
+		{"QmPeerA", true},
+		{"QmPeerB", false},
+	} {
+		data, _ := execute(t, m, "ping", tc.peer, "-j")["data"].(map[string]interface{})
+		if data["success"] != tc.success {
+			t.Errorf("%s: got %v", tc.peer, data)
+		}
+	}
+}
+
+func TestMockExecuteRequests(t *testing.T) {
+	m := NewMockDaemon()
+	for _, tc := range []struct {
+		method string
+		body   string
+		want   int
+	}{
+		{http.MethodGet, "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "not json", http.StatusBadRequest},
+	} {
+		w := httptest.NewRecorder()
+		m.Execute(w, httptest.NewRequest(tc.method, "/v3/execute", strings.NewReader(tc.body)))
+		if w.Code != tc.want {
+			t.Errorf("%s %q: got status %d, want %d", tc.method, tc.body, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// NewDaemonProxy returns a reverse proxy forwarding the daemon endpoints to
+// upstream. Responses are flushed as soon as they arrive so the event stream
+// is not buffered.
+func NewDaemonProxy(upstream string) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream daemon url %q", upstream)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.FlushInterval = -1
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		// the daemon is addressed as a local service, not as the dashboard host
+		r.Host = target.Host
+		r.Header.Del("Origin")
+		r.Header.Del("Referer")
+		r.Header.Del("Cookie")
+		r.Header.Del("Authorization")
+		r.Header.Del(csrfHeader)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		fmt.Println("Failed to reach upstream daemon", err)
+		http.Error(w, "hive daemon unreachable", http.StatusBadGateway)
+	}
+	return proxy, nil
+}
+
+// RegisterProxy mounts the daemon endpoints of upstream on mux.
+func RegisterProxy(mux *http.ServeMux, upstream string) error {
+	proxy, err := NewDaemonProxy(upstream)
+	if err != nil {
+		return err
+	}
+	mux.Handle("/v3/execute", proxy)
+	mux.Handle("/v3/events", proxy)
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDaemonProxy(t *testing.T) {
+	for _, upstream := range []string{"", "localhost:4343", "ftp://localhost:4343", "http://"} {
+		if _, err := NewDaemonProxy(upstream); err == nil {
+			t.Errorf("%q: got no error", upstream)
+		}
+	}
+}
+
+func TestProxyStripsHeaders(t *testing.T) {
+	var got http.Header
+	var host string
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		host = r.Host
+	}))
+	t.Cleanup(daemon.Close)
+	mux := http.NewServeMux()
+	if err := RegisterProxy(mux, daemon.URL); err != nil {
+		t.Fatal(err)
+	}
+	dashboard := httptest.NewServer(mux)
+	t.Cleanup(dashboard.Close)
+
+	req, err := http.NewRequest(http.MethodPost, dashboard.URL+"/v3/execute", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = "dashboard.example.com"
+	for key, value := range map[string]string{
+		"Origin":        "https://dashboard.example.com",
+		"Referer":       "https://dashboard.example.com/",
+		"Cookie":        sessionCookie + "=abc",
+		"Authorization": "Bearer s3cret",
+		csrfHeader:      "token",
+		"Content-Type":  "application/json",
+	} {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	for _, key := range []string{"Origin", "Referer", "Cookie", "Authorization", csrfHeader} {
+		if value := got.Get(key); value != "" {
+			t.Errorf("%s was forwarded: %q", key, value)
+		}
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type: got %q", got.Get("Content-Type"))
+	}
+	if want := strings.TrimPrefix(daemon.URL, "http://"); host != want {
+		t.Errorf("Host: got %q, want %q", host, want)
+	}
+}
+
+func TestProxyFlushesEvents(t *testing.T) {
+	release := make(chan struct{})
+	daemon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"result\":{\"topic\":\"Status\"}}\n"))
+		w.(http.Flusher).Flush()
+		// the second event only follows once the first one arrived
+		<-release
+		w.Write([]byte("{\"result\":{\"topic\":\"Balance\"}}\n"))
+	}))
+	t.Cleanup(daemon.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+	mux := http.NewServeMux()
+	if err := RegisterProxy(mux, daemon.URL); err != nil {
+		t.Fatal(err)
+	}
+	dashboard := httptest.NewServer(mux)
+	t.Cleanup(dashboard.Close)
+
+	resp, err := http.Post(dashboard.URL+"/v3/events", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, "Status") {
+			t.Errorf("first event: got %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first event was buffered by the proxy")
+	}
+	close(release)
+	if line := <-lines; !strings.Contains(line, "Balance") {
+		t.Errorf("second event: got %q", line)
+	}
+}
+
+func TestProxyUnreachableDaemon(t *testing.T) {
+	daemon := httptest.NewServer(http.NotFoundHandler())
+	upstream := daemon.URL
+	daemon.Close()
+	proxy, err := NewDaemonProxy(upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v3/execute", strings.NewReader("{}")))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "dashboard.crt")
+	keyFile := filepath.Join(dir, "dashboard.key")
+	if err := EnsureSelfSignedCert(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.VerifyHostname("localhost"); err != nil {
+		t.Error(err)
+	}
+	if err := cert.VerifyHostname("127.0.0.1"); err != nil {
+		t.Error(err)
+	}
+	if cert.NotAfter.Before(time.Now().Add(selfSignedValidity - 24*time.Hour)) {
+		t.Errorf("expires on %s", cert.NotAfter)
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file: %v, %v", info.Mode(), err)
+	}
+
+	// existing files are kept
+	before, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureSelfSignedCert(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	after, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("the certificate was regenerated")
+	}
+
+	// a missing key regenerates both
+	os.Remove(keyFile)
+	if err := EnsureSelfSignedCert(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,141 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"syscall/js"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+const (
+	AuthLoginPath   = "auth/login"
+	AuthLogoutPath  = "auth/logout"
+	AuthSessionPath = "auth/session"
+	CSRFHeader      = "X-CSRF-Token"
+)
+
+// Session is the dashboard server login of the current user
+type Session struct {
+	User string `json:"user"`
+	CSRF string `json:"csrf"`
+}
+
+var session Session
+
+// CheckSession asks the dashboard server for the current login. Servers
+// running without a login gate don't know the session endpoint, in which
+// case the dashboard is used without one.
+func CheckSession() {
+	resp, err := authRequest(http.MethodGet, AuthSessionPath, nil)
+	if err != nil {
+		log.Error("Error in checking session: ", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = json.NewDecoder(resp.Body).Decode(&session)
+		if err != nil {
+			log.Error("Error in decoding session: ", err.Error())
+			return
+		}
+		UseSession()
+		SetMultipleDisplay("LogoutButton", map[string]string{
+			"style": "display: block;",
+			"title": fmt.Sprintf("Logged in as %s", session.User),
+		})
+	case http.StatusUnauthorized:
+		ShowLogin()
+	default:
+		log.Debug("Dashboard server has no login")
+	}
+}
+
+// UseSession sends the CSRF token of the session with daemon requests when
+// they go through the dashboard server, other origins never see it
+func UseSession() {
+	if session.CSRF == "" {
+		return
+	}
+	origin := js.Global().Get("location").Get("origin").String()
+	if client.SameOrigin(hive.BaseURL(), origin) {
+		hive.SetHeader(CSRFHeader, session.CSRF)
+	}
+}
+
+// ShowLogin stops the event stream and shows the login screen
+func ShowLogin() {
+	go func() {
+		StopEvents()
+		SetDisplay("LogoutButton", "style", "display: none;")
+		SetDisplay("LoginScreen", "style", "display: flex;")
+	}()
+}
+
+func Login() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go func() {
+			SetDisplay("LoginStatus", "innerHTML", "Logging in....")
+			username := strings.TrimSpace(GetValue("LoginUsername", "value"))
+			password := GetValue("LoginPassword", "value")
+			credentials := map[string]string{"username": username, "password": password}
+			if username == "" {
+				credentials = map[string]string{"token": password}
+			}
+			buf, err := json.Marshal(credentials)
+			if err != nil {
+				log.Error("Error in marshalling credentials in Login: ", err.Error())
+				return
+			}
+			resp, err := authRequest(http.MethodPost, AuthLoginPath, bytes.NewReader(buf))
+			if err != nil {
+				log.Error("Error in logging in: ", err.Error())
+				SetDisplay("LoginStatus", "innerHTML", "Unable to reach the dashboard server")
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				SetDisplay("LoginStatus", "innerHTML", "Invalid credentials")
+				return
+			}
+			js.Global().Get("location").Call("reload")
+		}()
+		return nil
+	})
+}
+
+func Logout() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go func() {
+			resp, err := authRequest(http.MethodPost, AuthLogoutPath, nil)
+			if err != nil {
+				log.Error("Error in logging out: ", err.Error())
+				return
+			}
+			resp.Body.Close()
+			js.Global().Get("location").Call("reload")
+		}()
+		return nil
+	})
+}
+
+func authRequest(method string, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, ResolveEndpoint(path), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if session.CSRF != "" {
+		req.Header.Set(CSRFHeader, session.CSRF)
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -0,0 +1,250 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+const (
+	// BandwidthInterval is how often the dashboard polls the bandwidth
+	BandwidthInterval = 5 * time.Second
+	// BandwidthHistoryLength is how far back bandwidth samples are kept
+	BandwidthHistoryLength = 24 * time.Hour
+	// BandwidthChartPoints is the most points a bandwidth chart is drawn with
+	BandwidthChartPoints = 300
+)
+
+// BandwidthWindows are the spans the bandwidth chart can show
+var BandwidthWindows = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+// DefaultBandwidthWindow is shown until another window is selected
+const DefaultBandwidthWindow = "5m"
+
+// BandwidthHistory is a ring buffer of bandwidth samples, oldest first. It
+// holds a day of samples taken every BandwidthInterval.
+type BandwidthHistory struct {
+	mtx     sync.Mutex
+	samples []client.Bandwidth
+	start   int
+	count   int
+}
+
+var bandwidthHistory = NewBandwidthHistory(int(BandwidthHistoryLength / BandwidthInterval))
+
+// drawBandwidthChart redraws the bandwidth chart after a new sample, it is
+// set on js
+var drawBandwidthChart = func() {}
+
+func NewBandwidthHistory(size int) *BandwidthHistory {
+	if size < 1 {
+		size = 1
+	}
+	return &BandwidthHistory{samples: make([]client.Bandwidth, size)}
+}
+
+// Add appends a sample, dropping the oldest one when the buffer is full. A
+// sample without a time is taken now, and a sample with the time of the
+// latest one replaces it.
+func (h *BandwidthHistory) Add(sample client.Bandwidth) {
+	if sample.Time == 0 {
+		sample.Time = time.Now().Unix()
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.count > 0 {
+		last := (h.start + h.count - 1) % len(h.samples)
+		if h.samples[last].Time == sample.Time {
+			h.samples[last] = sample
+			return
+		}
+	}
+	if h.count < len(h.samples) {
+		h.samples[(h.start+h.count)%len(h.samples)] = sample
+		h.count++
+		return
+	}
+	h.samples[h.start] = sample
+	h.start = (h.start + 1) % len(h.samples)
+}
+
+// Len returns the number of samples held
+func (h *BandwidthHistory) Len() int {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.count
+}
+
+// Since returns the samples taken at or after t, oldest first
+func (h *BandwidthHistory) Since(t time.Time) []client.Bandwidth {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	since := t.Unix()
+	var samples []client.Bandwidth
+	for i := 0; i < h.count; i++ {
+		sample := h.samples[(h.start+i)%len(h.samples)]
+		if sample.Time >= since {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+// BandwidthStats summarizes a rate in bytes per second
+type BandwidthStats struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P95 float64 `json:"p95"`
+}
+
+// NewBandwidthStats summarizes rates, the 95th percentile is the nearest
+// rank
+func NewBandwidthStats(rates []float64) BandwidthStats {
+	if len(rates) == 0 {
+		return BandwidthStats{}
+	}
+	sorted := append([]float64(nil), rates...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, rate := range sorted {
+		sum += rate
+	}
+	rank := int(math.Ceil(0.95*float64(len(sorted)))) - 1
+	return BandwidthStats{
+		Min: sorted[0],
+		Avg: sum / float64(len(sorted)),
+		Max: sorted[len(sorted)-1],
+		P95: sorted[rank],
+	}
+}
+
+// BandwidthPoint is a point of the bandwidth chart, Time is in milliseconds
+// for javascript dates
+type BandwidthPoint struct {
+	Time int64   `json:"time"`
+	In   float64 `json:"in"`
+	Out  float64 `json:"out"`
+}
+
+// BandwidthReport is the bandwidth over a window, with at most
+// BandwidthChartPoints points to draw
+type BandwidthReport struct {
+	Window  string           `json:"window"`
+	Samples int              `json:"samples"`
+	Points  []BandwidthPoint `json:"points"`
+	In      BandwidthStats   `json:"in"`
+	Out     BandwidthStats   `json:"out"`
+}
+
+// Report summarizes the samples taken in the window ending at now
+func (h *BandwidthHistory) Report(window string, now time.Time) (*BandwidthReport, error) {
+	span, found := BandwidthWindows[window]
+	if !found {
+		return nil, fmt.Errorf("unknown bandwidth window %q", window)
+	}
+	samples := h.Since(now.Add(-span))
+	in := make([]float64, len(samples))
+	out := make([]float64, len(samples))
+	for i, sample := range samples {
+		in[i] = sample.Incoming
+		out[i] = sample.Outgoing
+	}
+	return &BandwidthReport{
+		Window:  window,
+		Samples: len(samples),
+		Points:  Downsample(samples, BandwidthChartPoints),
+		In:      NewBandwidthStats(in),
+		Out:     NewBandwidthStats(out),
+	}, nil
+}
+
+// Downsample averages consecutive samples into at most n points
+func Downsample(samples []client.Bandwidth, n int) []BandwidthPoint {
+	if n < 1 || len(samples) == 0 {
+		return []BandwidthPoint{}
+	}
+	size := (len(samples) + n - 1) / n
+	points := make([]BandwidthPoint, 0, (len(samples)+size-1)/size)
+	for i := 0; i < len(samples); i += size {
+		end := i + size
+		if end > len(samples) {
+			end = len(samples)
+		}
+		var point BandwidthPoint
+		for _, sample := range samples[i:end] {
+			point.In += sample.Incoming
+			point.Out += sample.Outgoing
+		}
+		count := float64(end - i)
+		point.In /= count
+		point.Out /= count
+		// a point is shown at the time of its latest sample
+		point.Time = samples[end-1].Time * 1000
+		points = append(points, point)
+	}
+	return points
+}
+
+// SelectedBandwidthWindow returns the window selected on the page
+func SelectedBandwidthWindow() string {
+	window, found := dom.Property("BandwidthWindow", "value")
+	if _, known := BandwidthWindows[window]; !found || !known {
+		return DefaultBandwidthWindow
+	}
+	return window
+}
+
+// RenderBandwidthHistory renders the stats of the selected window and
+// redraws its chart
+func RenderBandwidthHistory() {
+	report, err := bandwidthHistory.Report(SelectedBandwidthWindow(), time.Now())
+	if err != nil {
+		log.Error("Error in reporting Bandwidth: ", err.Error())
+		return
+	}
+	for direction, stats := range map[string]BandwidthStats{"In": report.In, "Out": report.Out} {
+		for name, rate := range map[string]float64{"Min": stats.Min, "Avg": stats.Avg, "Max": stats.Max, "P95": stats.P95} {
+			SetText(fmt.Sprintf("Bandwidth%s%s", direction, name), fmt.Sprintf("%s/s", Humanize(rate)))
+		}
+	}
+	SetText("BandwidthSamples", fmt.Sprintf("%d samples", report.Samples))
+	drawBandwidthChart()
+}
+
+var (
+	bandwidthPanelMtx  sync.Mutex
+	bandwidthPanelOpen bool
+)
+
+// ToggleBandwidthPanel opens or closes the bandwidth history panel and
+// reports whether it is open
+func ToggleBandwidthPanel() bool {
+	bandwidthPanelMtx.Lock()
+	bandwidthPanelOpen = !bandwidthPanelOpen
+	open := bandwidthPanelOpen
+	bandwidthPanelMtx.Unlock()
+	if !open {
+		SetDisplay("BandwidthPanel", "style", "display: none;")
+		return false
+	}
+	SetDisplay("BandwidthPanel", "style", "display: block;")
+	RenderBandwidthHistory()
+	return true
+}
+
+// BandwidthPanelOpen reports whether the bandwidth history panel is shown
+func BandwidthPanelOpen() bool {
+	bandwidthPanelMtx.Lock()
+	defer bandwidthPanelMtx.Unlock()
+	return bandwidthPanelOpen
+}
@@ -0,0 +1,60 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"time"
+)
+
+func init() {
+	drawBandwidthChart = func() {
+		if !BandwidthPanelOpen() {
+			return
+		}
+		err := callPageFunction("DrawBandwidthChart")
+		if err != nil {
+			log.Error("Error in drawing the bandwidth chart in drawBandwidthChart: ", err.Error())
+		}
+	}
+}
+
+// GetBandwidthHistory resolves the bandwidth report of a window, "5m", "1h"
+// or "24h", defaulting to the window selected on the page
+func GetBandwidthHistory() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		window := SelectedBandwidthWindow()
+		if len(args) > 0 && args[0].Type() == js.TypeString && args[0].String() != "" {
+			window = args[0].String()
+		}
+		report, err := bandwidthHistory.Report(window, time.Now())
+		if err != nil {
+			log.Error("Error in reporting Bandwidth in GetBandwidthHistory: ", err.Error())
+			return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(err.Error()))
+		}
+		val, err := json.Marshal(report)
+		if err != nil {
+			log.Error("Error in marshalling Bandwidth in GetBandwidthHistory: ", err.Error())
+			return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(err.Error()))
+		}
+		return js.Global().Get("Promise").Call("resolve", js.Global().Get("JSON").Call("parse", string(val)))
+	})
+}
+
+// ToggleBandwidthHistory opens or closes the bandwidth history panel
+func ToggleBandwidthHistory() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return ToggleBandwidthPanel()
+	})
+}
+
+// SelectBandwidthWindow rerenders the history after another window is picked
+func SelectBandwidthWindow() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		RenderBandwidthHistory()
+		return nil
+	})
+}
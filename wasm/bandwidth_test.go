@@ -0,0 +1,190 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestBandwidthHistory(t *testing.T) {
+	history := NewBandwidthHistory(3)
+	if got := history.Since(time.Unix(0, 0)); len(got) != 0 {
+		t.Fatalf("empty history returned %v", got)
+	}
+	for i := int64(1); i <= 5; i++ {
+		history.Add(client.Bandwidth{Incoming: float64(i), Time: 100 * i})
+	}
+	// a sample at the time of the latest one replaces it
+	history.Add(client.Bandwidth{Incoming: 50, Time: 500})
+	if history.Len() != 3 {
+		t.Fatalf("got %d samples, want 3", history.Len())
+	}
+	for _, tc := range []struct {
+		since int64
+		want  []float64
+	}{
+		{0, []float64{3, 4, 50}},
+		{400, []float64{4, 50}},
+		{401, []float64{50}},
+		{600, nil},
+	} {
+		got := history.Since(time.Unix(tc.since, 0))
+		if len(got) != len(tc.want) {
+			t.Errorf("since %d: got %v, want %v", tc.since, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i].Incoming != tc.want[i] {
+				t.Errorf("since %d: got %v, want %v", tc.since, got, tc.want)
+				break
+			}
+		}
+	}
+
+	before := time.Now().Unix()
+	history.Add(client.Bandwidth{Incoming: 6})
+	latest := history.Since(time.Unix(before, 0))
+	if len(latest) != 1 || latest[0].Time < before {
+		t.Errorf("a sample without a time was not taken now: %v", latest)
+	}
+}
+
+func TestNewBandwidthStats(t *testing.T) {
+	rates := make([]float64, 0, 100)
+	for i := 100; i > 0; i-- {
+		rates = append(rates, float64(i))
+	}
+	for _, tc := range []struct {
+		name  string
+		rates []float64
+		want  BandwidthStats
+	}{
+		{"empty", nil, BandwidthStats{}},
+		{"single", []float64{1024}, BandwidthStats{Min: 1024, Avg: 1024, Max: 1024, P95: 1024}},
+		{"nearest rank", []float64{5, 1, 4, 2, 3}, BandwidthStats{Min: 1, Avg: 3, Max: 5, P95: 5}},
+		{"hundred", rates, BandwidthStats{Min: 1, Avg: 50.5, Max: 100, P95: 95}},
+	} {
+		if got := NewBandwidthStats(tc.rates); got != tc.want {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+	if rates[0] != 100 {
+		t.Error("stats sorted the rates in place")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	samples := make([]client.Bandwidth, 0, 5)
+	for i := int64(1); i <= 5; i++ {
+		samples = append(samples, client.Bandwidth{Incoming: float64(i), Outgoing: float64(10 * i), Time: i})
+	}
+	for _, tc := range []struct {
+		n    int
+		want []BandwidthPoint
+	}{
+		{0, []BandwidthPoint{}},
+		{2, []BandwidthPoint{{Time: 3000, In: 2, Out: 20}, {Time: 5000, In: 4.5, Out: 45}}},
+		{5, []BandwidthPoint{{1000, 1, 10}, {2000, 2, 20}, {3000, 3, 30}, {4000, 4, 40}, {5000, 5, 50}}},
+		{10, []BandwidthPoint{{1000, 1, 10}, {2000, 2, 20}, {3000, 3, 30}, {4000, 4, 40}, {5000, 5, 50}}},
+	} {
+		got := Downsample(samples, tc.n)
+		if len(got) != len(tc.want) {
+			t.Errorf("%d points: got %v, want %v", tc.n, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%d points: got %v, want %v", tc.n, got, tc.want)
+				break
+			}
+		}
+	}
+	if got := Downsample(nil, 10); len(got) != 0 {
+		t.Errorf("downsampled nothing into %v", got)
+	}
+}
+
+func TestBandwidthReport(t *testing.T) {
+	now := time.Unix(1609459200, 0)
+	history := NewBandwidthHistory(int(BandwidthHistoryLength / BandwidthInterval))
+	// two hours of samples, one every BandwidthInterval
+	for at := now.Add(-2 * time.Hour); !at.After(now); at = at.Add(BandwidthInterval) {
+		history.Add(client.Bandwidth{Incoming: 1024, Outgoing: 2048, Time: at.Unix()})
+	}
+	for _, tc := range []struct {
+		window  string
+		samples int
+		points  int
+	}{
+		{"5m", 61, 61},
+		{"1h", 721, 241},
+		{"24h", 1441, 289},
+	} {
+		report, err := history.Report(tc.window, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Samples != tc.samples || len(report.Points) != tc.points {
+			t.Errorf("%s: got %d samples in %d points, want %d in %d", tc.window, report.Samples, len(report.Points), tc.samples, tc.points)
+		}
+		if report.In.Avg != 1024 || report.Out.P95 != 2048 {
+			t.Errorf("%s: got stats %+v %+v", tc.window, report.In, report.Out)
+		}
+		if last := report.Points[len(report.Points)-1]; last.Time != now.Unix()*1000 {
+			t.Errorf("%s: last point at %d, want %d", tc.window, last.Time, now.Unix()*1000)
+		}
+	}
+	if _, err := history.Report("1w", now); err == nil {
+		t.Error("reported an unknown window")
+	}
+}
+
+func TestRenderBandwidthHistory(t *testing.T) {
+	fake := useFakeDOM("BandwidthWindow", "BandwidthPanel", "BandwidthInMin", "BandwidthInAvg", "BandwidthInMax", "BandwidthInP95",
+		"BandwidthOutMin", "BandwidthOutAvg", "BandwidthOutMax", "BandwidthOutP95", "BandwidthSamples")
+	saved := bandwidthHistory
+	t.Cleanup(func() { bandwidthHistory = saved })
+	bandwidthHistory = NewBandwidthHistory(10)
+	now := time.Now()
+	bandwidthHistory.Add(client.Bandwidth{Incoming: 512, Outgoing: 0, Time: now.Add(-30 * time.Minute).Unix()})
+	bandwidthHistory.Add(client.Bandwidth{Incoming: 1024, Outgoing: 1536, Time: now.Add(-10 * time.Second).Unix()})
+	bandwidthHistory.Add(client.Bandwidth{Incoming: 3072, Outgoing: 512, Time: now.Unix()})
+
+	for _, tc := range []struct {
+		window string
+		want   map[string]string
+	}{
+		// an unknown selection falls back to the default window
+		{"", map[string]string{
+			"BandwidthInMin": "1.0 KB/s", "BandwidthInAvg": "2.0 KB/s", "BandwidthInMax": "3.0 KB/s", "BandwidthInP95": "3.0 KB/s",
+			"BandwidthOutMin": "512.0 B/s", "BandwidthOutAvg": "1.0 KB/s", "BandwidthOutMax": "1.5 KB/s", "BandwidthOutP95": "1.5 KB/s",
+			"BandwidthSamples": "2 samples",
+		}},
+		{"1h", map[string]string{
+			"BandwidthInMin": "512.0 B/s", "BandwidthInMax": "3.0 KB/s",
+			"BandwidthOutMin": "0.0 B/s", "BandwidthSamples": "3 samples",
+		}},
+	} {
+		fake.SetProperty("BandwidthWindow", "value", tc.window)
+		RenderBandwidthHistory()
+		for id, want := range tc.want {
+			if got, _ := fake.Property(id, "textContent"); got != want {
+				t.Errorf("%q %s: got %q, want %q", tc.window, id, got, want)
+			}
+		}
+	}
+
+	if !ToggleBandwidthPanel() || !BandwidthPanelOpen() {
+		t.Error("the panel did not open")
+	}
+	if got, _ := fake.Property("BandwidthPanel", "style"); got != "display: block;" {
+		t.Errorf("open panel style %q", got)
+	}
+	if ToggleBandwidthPanel() || BandwidthPanelOpen() {
+		t.Error("the panel did not close")
+	}
+	if got, _ := fake.Property("BandwidthPanel", "style"); got != "display: none;" {
+		t.Errorf("closed panel style %q", got)
+	}
+}
@@ -0,0 +1,174 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DOMTag is the struct tag Bind renders fields with:
+//
+//	Field float64 `dom:"elementId,format=bytes,empty=Not Running"`
+//
+// format is one of bytes, percent, swrm, gb or bool:TRUE/FALSE and defaults to
+// the plain value. empty replaces empty strings. Untagged struct fields are
+// walked into, fields tagged "-" and untagged scalars are skipped.
+const DOMTag = "dom"
+
+type domBinding struct {
+	id     string
+	format string
+	empty  string
+}
+
+func parseDOMTag(tag string) (domBinding, error) {
+	parts := strings.Split(tag, ",")
+	b := domBinding{id: strings.TrimSpace(parts[0])}
+	if b.id == "" {
+		return b, fmt.Errorf("missing element id in dom tag %q", tag)
+	}
+	for _, part := range parts[1:] {
+		idx := strings.Index(part, "=")
+		if idx < 0 {
+			return b, fmt.Errorf("invalid option %q in dom tag %q", part, tag)
+		}
+		switch key, value := part[:idx], part[idx+1:]; key {
+		case "format":
+			b.format = value
+		case "empty":
+			b.empty = value
+		default:
+			return b, fmt.Errorf("unknown option %q in dom tag %q", key, tag)
+		}
+	}
+	return b, nil
+}
+
+// Bindings returns the text of every element bound by the dom tags of v, a
+// struct or a pointer to one, by element id.
+func Bindings(v interface{}) (map[string]string, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return nil, fmt.Errorf("cannot bind nil")
+	}
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, fmt.Errorf("cannot bind nil %s", value.Type())
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot bind %s, expected a struct", value.Type())
+	}
+	bindings := make(map[string]string)
+	err := collectBindings(value, bindings)
+	if err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+func collectBindings(value reflect.Value, bindings map[string]string) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, tagged := field.Tag.Lookup(DOMTag)
+		if tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct {
+				err := collectBindings(value.Field(i), bindings)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		b, err := parseDOMTag(tag)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", value.Type(), field.Name, err.Error())
+		}
+		text, err := FormatValue(value.Field(i).Interface(), b.format)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %s", value.Type(), field.Name, err.Error())
+		}
+		if text == "" && b.empty != "" {
+			text = b.empty
+		}
+		bindings[b.id] = text
+	}
+	return nil
+}
+
+// FormatValue renders a field value in one of the dom tag formats
+func FormatValue(v interface{}, format string) (string, error) {
+	if strings.HasPrefix(format, "bool:") {
+		labels := strings.SplitN(strings.TrimPrefix(format, "bool:"), "/", 2)
+		b, ok := v.(bool)
+		if !ok || len(labels) != 2 {
+			return "", fmt.Errorf("format %q needs a bool and TRUE/FALSE labels", format)
+		}
+		if b {
+			return labels[0], nil
+		}
+		return labels[1], nil
+	}
+	if format == "" {
+		return fmt.Sprintf("%v", v), nil
+	}
+	number, err := toFloat(v)
+	if err != nil {
+		return "", fmt.Errorf("format %q: %s", format, err.Error())
+	}
+	switch format {
+	case "bytes":
+		return Humanize(number), nil
+	case "percent":
+		return fmt.Sprintf("%.2f %s", number, "%"), nil
+	case "gb":
+		return fmt.Sprintf("%.2f %s", number, "GB"), nil
+	case "swrm":
+		return fmt.Sprintf("%s %s", TruncateDecimals(number, 4), "SWRM"), nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+// TruncateDecimals formats value with at most places decimals, cutting off
+// the rest instead of rounding so balances are never shown higher than they are.
+func TruncateDecimals(value float64, places int) string {
+	sFloat := strconv.FormatFloat(value, 'f', -1, 64)
+	idx := strings.Index(sFloat, ".")
+	if idx >= 0 && idx+places+1 < len(sFloat) {
+		sFloat = sFloat[:idx+places+1]
+	}
+	return sFloat
+}
+
+func toFloat(v interface{}) (float64, error) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), nil
+	}
+	return 0, fmt.Errorf("%T is not a number", v)
+}
+
+// Bind renders the dom tagged fields of v into their elements. Elements that
+// are not on the current page are skipped, so the same struct can be bound on
+// every page.
+func Bind(v interface{}) error {
+	bindings, err := Bindings(v)
+	if err != nil {
+		return err
+	}
+	for id, text := range bindings {
+		SetText(id, text)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestTruncateDecimals(t *testing.T) {
+	for _, tc := range []struct {
+		value  float64
+		places int
+		want   string
+	}{
+		{12.3456789, 4, "12.3456"},
+		{12.99999, 4, "12.9999"},
+		{12.5, 4, "12.5"},
+		{12, 4, "12"},
+		{-0.123456, 4, "-0.1234"},
+		{0.00001, 4, "0.0000"},
+		{1e21, 4, "1000000000000000000000"},
+	} {
+		if got := TruncateDecimals(tc.value, tc.places); got != tc.want {
+			t.Errorf("TruncateDecimals(%v, %d): got %q, want %q", tc.value, tc.places, got, tc.want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		value  interface{}
+		format string
+		want   string
+		err    bool
+	}{
+		{"v0.2.14", "", "v0.2.14", false},
+		{int64(42), "", "42", false},
+		{float64(1536), "bytes", "1.5 KB", false},
+		{int64(5 * 1099511627776), "bytes", "5.0 TB", false},
+		{uint32(1024), "bytes", "1.0 KB", false},
+		{98.256, "percent", "98.26 %", false},
+		{1234.5678, "gb", "1234.57 GB", false},
+		{-0.56789, "swrm", "-0.5678 SWRM", false},
+		{true, "bool:ONLINE/OFFLINE", "ONLINE", false},
+		{false, "bool:ONLINE/OFFLINE", "OFFLINE", false},
+		{"yes", "bool:ONLINE/OFFLINE", "", true},
+		{true, "bool:ONLINE", "", true},
+		{"1024", "bytes", "", true},
+		{1024, "hex", "", true},
+	} {
+		got, err := FormatValue(tc.value, tc.format)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("FormatValue(%v, %q): got %q %v, want %q", tc.value, tc.format, got, err, tc.want)
+		}
+	}
+}
+
+func TestBindings(t *testing.T) {
+	got, err := Bindings(&client.Status{
+		LoggedIn:              true,
+		TotalUptimePercentage: client.UptimePercentage{Percentage: 99.5},
+		ServerDetails:         client.ServerStatus{Rpc: "Running"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"LoggedIn":         "LoggedIn",
+		"DaemonRunning":    "OFFLINE",
+		"percentageNumber": "99.50 %",
+		"Rpc":              "Running",
+		"Http":             "Not Running",
+		"Proxy":            "Not Running",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, v := range []interface{}{
+		nil,
+		(*client.Status)(nil),
+		"text",
+		struct {
+			A string `dom:""`
+		}{},
+		struct {
+			A string `dom:"A,colour=red"`
+		}{},
+		struct {
+			A string `dom:"A,format=bytes"`
+		}{},
+	} {
+		if _, err := Bindings(v); err == nil {
+			t.Errorf("Bindings(%#v) succeeded", v)
+		}
+	}
+}
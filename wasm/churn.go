@@ -0,0 +1,284 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+	"github.com/hako/durafmt"
+)
+
+const (
+	// ChurnWindow is the span the churn rate is measured over
+	ChurnWindow = 10 * time.Minute
+	// MaxPeerEvents is how many joins and leaves the timeline keeps
+	MaxPeerEvents = 500
+	// TimelineLength is how many of the latest events the timeline shows
+	TimelineLength = 100
+
+	PeerJoined = "joined"
+	PeerLeft   = "left"
+)
+
+// PeerEvent is a peer joining or leaving the swarm. Session is how long a
+// peer that left was connected, zero when it was connected before the
+// dashboard started tracking it.
+type PeerEvent struct {
+	PeerID  string        `json:"peerId"`
+	Change  string        `json:"change"`
+	Time    time.Time     `json:"time"`
+	Session time.Duration `json:"session,omitempty"`
+}
+
+func (e *PeerEvent) GetNamespace() string {
+	return "PeerEvent"
+}
+func (e *PeerEvent) GetId() string {
+	return e.PeerID
+}
+func (e *PeerEvent) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+func (e *PeerEvent) Unmarshal(val []byte) error {
+	return json.Unmarshal(val, e)
+}
+
+// PeerSession is how long a peer has been connected. Known is false when the
+// peer was already connected when tracking started, Since is then when it
+// was first seen.
+type PeerSession struct {
+	Since time.Time
+	Known bool
+}
+
+// PeerTracker diffs successive swarm peers snapshots by peer ID into joins
+// and leaves
+type PeerTracker struct {
+	mtx       sync.Mutex
+	started   bool
+	connected map[string]PeerSession
+	events    []PeerEvent
+}
+
+var peerTracker = NewPeerTracker()
+
+func NewPeerTracker() *PeerTracker {
+	return &PeerTracker{connected: make(map[string]PeerSession)}
+}
+
+// Update diffs the peers of a swarm peers snapshot taken at now against the
+// previous one and returns the joins and leaves. The first snapshot only
+// starts the sessions of the peers in it, unless a restored event tells when
+// they joined.
+func (p *PeerTracker) Update(peers []string, now time.Time) []PeerEvent {
+	addrs, _ := client.ParseMultiaddrs(peers)
+	current := make(map[string]bool)
+	for _, addr := range addrs {
+		if addr.PeerID != "" {
+			current[addr.PeerID] = true
+		}
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if !p.started {
+		p.started = true
+		joined := p.lastJoins()
+		for id := range current {
+			session := PeerSession{Since: now}
+			if at, found := joined[id]; found {
+				session = PeerSession{Since: at, Known: true}
+			}
+			p.connected[id] = session
+		}
+		return nil
+	}
+	var changes []PeerEvent
+	for id, session := range p.connected {
+		if current[id] {
+			continue
+		}
+		event := PeerEvent{PeerID: id, Change: PeerLeft, Time: now}
+		if session.Known {
+			event.Session = now.Sub(session.Since)
+		}
+		changes = append(changes, event)
+		delete(p.connected, id)
+	}
+	for id := range current {
+		if _, found := p.connected[id]; found {
+			continue
+		}
+		changes = append(changes, PeerEvent{PeerID: id, Change: PeerJoined, Time: now})
+		p.connected[id] = PeerSession{Since: now, Known: true}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Change != changes[j].Change {
+			return changes[i].Change == PeerLeft
+		}
+		return changes[i].PeerID < changes[j].PeerID
+	})
+	p.add(changes...)
+	return changes
+}
+
+// lastJoins returns when peers whose latest event is a join joined
+func (p *PeerTracker) lastJoins() map[string]time.Time {
+	joined := make(map[string]time.Time)
+	for _, event := range p.events {
+		if event.Change == PeerJoined {
+			joined[event.PeerID] = event.Time
+		} else {
+			delete(joined, event.PeerID)
+		}
+	}
+	return joined
+}
+
+func (p *PeerTracker) add(events ...PeerEvent) {
+	p.events = append(p.events, events...)
+	if len(p.events) > MaxPeerEvents {
+		p.events = append([]PeerEvent(nil), p.events[len(p.events)-MaxPeerEvents:]...)
+	}
+}
+
+// Restore adds events saved before the page was reloaded, oldest first
+func (p *PeerTracker) Restore(events []PeerEvent) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.add(events...)
+}
+
+// Session returns the session of a connected peer
+func (p *PeerTracker) Session(peerID string) (PeerSession, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	session, found := p.connected[peerID]
+	return session, found
+}
+
+// Events returns the latest n events, newest first
+func (p *PeerTracker) Events(n int) []PeerEvent {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if n > len(p.events) {
+		n = len(p.events)
+	}
+	events := make([]PeerEvent, 0, n)
+	for i := len(p.events) - 1; i >= len(p.events)-n; i-- {
+		events = append(events, p.events[i])
+	}
+	return events
+}
+
+// Churn is how many peers joined and left over ChurnWindow
+type Churn struct {
+	Joined int     `json:"joined"`
+	Left   int     `json:"left"`
+	Rate   float64 `json:"rate"`
+	Peers  int     `json:"peers"`
+}
+
+// Churn counts the joins and leaves of the ChurnWindow ending at now. Rate
+// is the changes per minute.
+func (p *PeerTracker) Churn(now time.Time) Churn {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	churn := Churn{Peers: len(p.connected)}
+	for _, event := range p.events {
+		if now.Sub(event.Time) > ChurnWindow || event.Time.After(now) {
+			continue
+		}
+		if event.Change == PeerJoined {
+			churn.Joined++
+		} else {
+			churn.Left++
+		}
+	}
+	churn.Rate = float64(churn.Joined+churn.Left) / ChurnWindow.Minutes()
+	return churn
+}
+
+var (
+	peerTimelineMtx  sync.Mutex
+	peerTimelineOpen bool
+)
+
+// TogglePeerTimelinePanel opens or closes the timeline of joins and leaves
+// and reports whether it is open
+func TogglePeerTimelinePanel() bool {
+	peerTimelineMtx.Lock()
+	peerTimelineOpen = !peerTimelineOpen
+	open := peerTimelineOpen
+	peerTimelineMtx.Unlock()
+	if !open {
+		SetDisplay("PeerTimelinePanel", "style", "display: none;")
+		return false
+	}
+	SetDisplay("PeerTimelinePanel", "style", "display: block;")
+	RenderChurn()
+	return true
+}
+
+// FormatSession renders how long a peer has been connected, "≥" marks a
+// session that started before tracking did
+func FormatSession(session PeerSession, now time.Time) string {
+	elapsed := now.Sub(session.Since).Round(time.Second)
+	text := durafmt.Parse(elapsed).LimitFirstN(2).String()
+	if elapsed < time.Second {
+		text = "0 seconds"
+	}
+	if !session.Known {
+		return "≥ " + text
+	}
+	return text
+}
+
+// FormatPeerEvent renders an event of the timeline in loc
+func FormatPeerEvent(event PeerEvent, loc *time.Location) string {
+	text := fmt.Sprintf("%s %s %s", event.Time.In(loc).Format("15:04:05"), event.Change, ShortPeerID(event.PeerID))
+	if event.Session > 0 {
+		text += " after " + durafmt.Parse(event.Session.Round(time.Second)).LimitFirstN(2).String()
+	}
+	return text
+}
+
+// TrackPeers records the joins and leaves of a swarm peers snapshot
+func TrackPeers(peers []string, now time.Time) {
+	for _, event := range peerTracker.Update(peers, now) {
+		event := event
+		if event.Change == PeerLeft {
+			// a peer that comes back doesn't show the results of its last session
+			clearPeerResult(event.PeerID)
+		}
+		RecordHistory(&event)
+	}
+	RenderChurn()
+}
+
+// RenderChurn renders the churn rate and the timeline of joins and leaves
+func RenderChurn() {
+	now := time.Now()
+	churn := peerTracker.Churn(now)
+	SetText("PeerChurn", fmt.Sprintf("Churn %.1f/min: %d joined, %d left in %s",
+		churn.Rate, churn.Joined, churn.Left, durafmt.Parse(ChurnWindow)))
+	SetDisplay("PeerTimeline", "innerHTML", "")
+	events := peerTracker.Events(TimelineLength)
+	if len(events) == 0 {
+		dom.AppendChild("PeerTimeline", "div", map[string]string{
+			"className":   "PeerEvent_Class",
+			"textContent": "No peers joined or left yet",
+		})
+		return
+	}
+	for _, event := range events {
+		dom.AppendChild("PeerTimeline", "div", map[string]string{
+			"className":   "PeerEvent_Class " + event.Change,
+			"textContent": FormatPeerEvent(event, time.Local),
+			"title":       event.PeerID,
+		})
+	}
+}
@@ -0,0 +1,34 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"time"
+)
+
+// GetPeerChurn resolves the churn of the last ChurnWindow and the latest
+// joins and leaves, newest first, as {churn, events}
+func GetPeerChurn() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		val, err := json.Marshal(map[string]interface{}{
+			"churn":  peerTracker.Churn(time.Now()),
+			"events": peerTracker.Events(TimelineLength),
+		})
+		if err != nil {
+			log.Error("Error in marshalling Peer Churn in GetPeerChurn: ", err.Error())
+			return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(err.Error()))
+		}
+		return js.Global().Get("Promise").Call("resolve", js.Global().Get("JSON").Call("parse", string(val)))
+	})
+}
+
+// TogglePeerTimeline opens or closes the timeline of joins and leaves
+func TogglePeerTimeline() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return TogglePeerTimelinePanel()
+	})
+}
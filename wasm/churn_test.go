@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func peerAddr(id string) string {
+	return "/ip4/198.51.100.7/tcp/4001/p2p/" + id
+}
+
+func changes(events []PeerEvent) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Change+" "+event.PeerID)
+	}
+	return result
+}
+
+func TestPeerTrackerUpdate(t *testing.T) {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	p := NewPeerTracker()
+	// the first snapshot is the baseline
+	if got := p.Update([]string{peerAddr("QmA"), peerAddr("QmB"), "/unix/tmp/hive.sock"}, start); len(got) != 0 {
+		t.Errorf("baseline: got %v", got)
+	}
+	session, found := p.Session("QmA")
+	if !found || session.Known || !session.Since.Equal(start) {
+		t.Errorf("baseline session: got %+v, %v", session, found)
+	}
+
+	// a peer connected over two addresses is one peer
+	got := p.Update([]string{peerAddr("QmB"), peerAddr("QmD"), peerAddr("QmC"), "/ip4/198.51.100.8/udp/4001/quic/p2p/QmC"}, start.Add(time.Minute))
+	if want := []string{"left QmA", "joined QmC", "joined QmD"}; !reflect.DeepEqual(changes(got), want) {
+		t.Errorf("got %v, want %v", changes(got), want)
+	}
+	if got[0].Session != 0 {
+		t.Errorf("QmA was connected before tracking, got a session of %s", got[0].Session)
+	}
+	if _, found := p.Session("QmA"); found {
+		t.Error("QmA has a session after leaving")
+	}
+
+	got = p.Update([]string{peerAddr("QmB")}, start.Add(3*time.Minute))
+	if want := []string{"left QmC", "left QmD"}; !reflect.DeepEqual(changes(got), want) {
+		t.Errorf("got %v, want %v", changes(got), want)
+	}
+	if got[0].Session != 2*time.Minute {
+		t.Errorf("QmC session: got %s", got[0].Session)
+	}
+	if got := p.Update([]string{peerAddr("QmB")}, start.Add(4*time.Minute)); len(got) != 0 {
+		t.Errorf("unchanged peers: got %v", got)
+	}
+
+	events := p.Events(2)
+	if want := []string{"left QmD", "left QmC"}; !reflect.DeepEqual(changes(events), want) {
+		t.Errorf("latest events: got %v, want %v", changes(events), want)
+	}
+	if events := p.Events(TimelineLength); len(events) != 5 {
+		t.Errorf("got %d events, want 5", len(events))
+	}
+}
+
+func TestPeerTrackerRestore(t *testing.T) {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	p := NewPeerTracker()
+	p.Restore([]PeerEvent{
+		{PeerID: "QmA", Change: PeerJoined, Time: start},
+		{PeerID: "QmB", Change: PeerJoined, Time: start},
+		{PeerID: "QmB", Change: PeerLeft, Time: start.Add(time.Minute)},
+	})
+	now := start.Add(time.Hour)
+	p.Update([]string{peerAddr("QmA"), peerAddr("QmB")}, now)
+	// QmA joined before the reload, QmB came back while the page was closed
+	if session, _ := p.Session("QmA"); !session.Known || !session.Since.Equal(start) {
+		t.Errorf("QmA: got %+v", session)
+	}
+	if session, _ := p.Session("QmB"); session.Known || !session.Since.Equal(now) {
+		t.Errorf("QmB: got %+v", session)
+	}
+	got := p.Update(nil, now.Add(time.Minute))
+	if len(got) != 2 || got[0].PeerID != "QmA" || got[0].Session != time.Hour+time.Minute || got[1].Session != 0 {
+		t.Errorf("got %+v", got)
+	}
+
+	var many []PeerEvent
+	for i := 0; i < MaxPeerEvents+10; i++ {
+		many = append(many, PeerEvent{PeerID: fmt.Sprintf("Qm%d", i), Change: PeerJoined, Time: now})
+	}
+	p.Restore(many)
+	if events := p.Events(MaxPeerEvents + 10); len(events) != MaxPeerEvents || events[0].PeerID != fmt.Sprintf("Qm%d", MaxPeerEvents+9) {
+		t.Errorf("got %d events, the latest %+v", len(events), events[0])
+	}
+}
+
+func TestPeerTrackerChurn(t *testing.T) {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	p := NewPeerTracker()
+	p.Update([]string{peerAddr("QmA")}, start)
+	p.Update([]string{peerAddr("QmB"), peerAddr("QmC")}, start.Add(time.Minute))
+	p.Update([]string{peerAddr("QmB")}, start.Add(ChurnWindow+2*time.Minute))
+
+	if got, want := p.Churn(start.Add(ChurnWindow)), (Churn{Joined: 2, Left: 1, Rate: 0.3, Peers: 1}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	// the first changes fell out of the window
+	if got, want := p.Churn(start.Add(ChurnWindow+2*time.Minute)), (Churn{Left: 1, Rate: 0.1, Peers: 1}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatSession(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		session PeerSession
+		want    string
+	}{
+		{PeerSession{Since: now, Known: true}, "0 seconds"},
+		{PeerSession{Since: now.Add(-90 * time.Second), Known: true}, "1 minute 30 seconds"},
+		{PeerSession{Since: now.Add(-(26*time.Hour + 3*time.Minute + 4*time.Second)), Known: true}, "1 day 2 hours"},
+		{PeerSession{Since: now.Add(-45 * time.Second)}, "≥ 45 seconds"},
+	} {
+		if got := FormatSession(tc.session, now); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.session, got, tc.want)
+		}
+	}
+
+	event := PeerEvent{PeerID: "QmPeerWithAVeryLongIdentifier", Change: PeerLeft, Time: now, Session: 2*time.Hour + time.Second}
+	if got, want := FormatPeerEvent(event, time.UTC), "10:00:00 left QmPeerWi…tifier after 2 hours 1 second"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	event = PeerEvent{PeerID: "QmB", Change: PeerJoined, Time: now}
+	if got, want := FormatPeerEvent(event, time.UTC), "10:00:00 joined QmB"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderChurn(t *testing.T) {
+	resetPeerTracker(t)
+	fake := useFakeDOM("PeerChurn", "PeerTimeline", "PeerTimelinePanel")
+	RenderChurn()
+	if got := fake.Children("PeerTimeline", "textContent"); !reflect.DeepEqual(got, []string{"No peers joined or left yet"}) {
+		t.Errorf("empty timeline: got %q", got)
+	}
+
+	now := time.Now()
+	TrackPeers([]string{peerAddr("QmA")}, now.Add(-2*time.Minute))
+	TrackPeers([]string{peerAddr("QmB")}, now.Add(-time.Minute))
+	if got, want := fake.Children("PeerTimeline", "className"), []string{"PeerEvent_Class joined", "PeerEvent_Class left"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline: got %q, want %q", got, want)
+	}
+	if got := fake.Children("PeerTimeline", "title"); !reflect.DeepEqual(got, []string{"QmB", "QmA"}) {
+		t.Errorf("timeline peers: got %q", got)
+	}
+	if got, _ := fake.Property("PeerChurn", "textContent"); got != "Churn 0.2/min: 1 joined, 1 left in 10 minutes" {
+		t.Errorf("PeerChurn: got %q", got)
+	}
+
+	if !TogglePeerTimelinePanel() {
+		t.Error("the timeline didn't open")
+	}
+	if got, _ := fake.Property("PeerTimelinePanel", "style"); got != "display: block;" {
+		t.Errorf("open panel style: got %q", got)
+	}
+	if TogglePeerTimelinePanel() {
+		t.Error("the timeline didn't close")
+	}
+}
@@ -0,0 +1,501 @@
+// Package client is a typed client for the hive daemon's execute gateway.
+//
+// Every hive-cli command the dashboard needs is exposed as a method on Client
+// which returns the decoded payload from types.go, so the daemon can be driven
+// from plain Go code without going through the DOM bound js.Func wrappers.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ExecutePath is the daemon endpoint that runs a single hive-cli command
+	ExecutePath = "/v3/execute"
+	// EventsPath is the daemon endpoint that streams topic events
+	EventsPath = "/v3/events"
+
+	// StatusOK is the Out.Status reported by the daemon for a successful command
+	StatusOK = 200
+)
+
+// Error is returned when the gateway could not run a command or the daemon
+// reported a non-successful Out for it.
+type Error struct {
+	Command string
+	Status  int
+	Message string
+	Details string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: status %d", e.Command, e.Status)
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.Details != "" {
+		msg = fmt.Sprintf("%s (%s)", msg, e.Details)
+	}
+	return msg
+}
+
+// Client talks to a hive daemon over its HTTP gateway.
+type Client struct {
+	mtx          sync.RWMutex
+	baseURL      string
+	binary       string
+	headers      http.Header
+	unauthorized func()
+	onError      func(error)
+	http         *http.Client
+
+	requestTimeout time.Duration
+
+	flightMtx  sync.Mutex
+	calls      map[string]*call
+	cache      map[string]cacheEntry
+	cacheTTL   time.Duration
+	generation uint64
+	slots      chan struct{}
+}
+
+// New returns a Client for the daemon listening at baseURL,
+// e.g. "http://localhost:4343".
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		binary:   DefaultBinary,
+		headers:  make(http.Header),
+		http:     http.DefaultClient,
+		calls:    make(map[string]*call),
+		cache:    make(map[string]cacheEntry),
+		cacheTTL: DefaultCacheTTL,
+		slots:    make(chan struct{}, DefaultMaxInFlight),
+
+		requestTimeout: DefaultRequestTimeout,
+	}
+}
+
+// SetHeader sets a header sent with every request to the daemon, e.g. the
+// CSRF token of the dashboard session.
+func (c *Client) SetHeader(key, value string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.headers.Set(key, value)
+}
+
+// SetUnauthorizedHandler sets a function called whenever the daemon or the
+// dashboard server in front of it rejects a request as unauthorized.
+func (c *Client) SetUnauthorizedHandler(fn func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.unauthorized = fn
+}
+
+// SetErrorHandler sets a function called with every failed command, except
+// for canceled ones and those rejected as unauthorized.
+func (c *Client) SetErrorHandler(fn func(error)) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.onError = fn
+}
+
+// ParseBaseURL validates a daemon base URL and returns it in the form
+// expected by New and SetBaseURL.
+func ParseBaseURL(baseURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme in daemon url %q", baseURL)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host in daemon url %q", baseURL)
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// SetBaseURL points the client at another daemon. Requests already in
+// flight keep using the previous endpoint, but their results are not
+// cached. Headers set with SetHeader are dropped when the origin changes,
+// they belong to the server they were set for.
+func (c *Client) SetBaseURL(baseURL string) error {
+	parsed, err := ParseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	if !SameOrigin(c.baseURL, parsed) {
+		c.headers = make(http.Header)
+	}
+	c.baseURL = parsed
+	c.mtx.Unlock()
+	c.Invalidate()
+	return nil
+}
+
+// SameOrigin reports whether two URLs share scheme, host and port.
+func SameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return ua.Scheme != "" && strings.EqualFold(ua.Scheme, ub.Scheme) &&
+		strings.EqualFold(originHost(ua), originHost(ub))
+}
+
+// originHost is the host of u with its port, the scheme's default port when
+// none is given
+func originHost(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if strings.EqualFold(u.Scheme, "https") {
+			port = "443"
+		}
+	}
+	return u.Hostname() + ":" + port
+}
+
+// BaseURL returns the daemon base URL currently in use.
+func (c *Client) BaseURL() string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.baseURL
+}
+
+// SetBinary sets the hive-cli binary name the daemon runs commands with.
+// Cached results of the previous binary are dropped.
+func (c *Client) SetBinary(binary string) error {
+	if err := ValidateArg(binary); err != nil {
+		return fmt.Errorf("invalid hive-cli binary: %s", err.Error())
+	}
+	c.mtx.Lock()
+	c.binary = binary
+	c.mtx.Unlock()
+	c.Invalidate()
+	return nil
+}
+
+// Binary returns the hive-cli binary name currently in use.
+func (c *Client) Binary() string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.binary
+}
+
+// DetectBinary asks the daemon for its platform and switches to the hive-cli
+// binary name used there. Daemons that don't report a platform keep the
+// current binary.
+func (c *Client) DetectBinary(ctx context.Context) error {
+	version, err := c.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if version.Platform == "" {
+		return nil
+	}
+	return c.SetBinary(BinaryFor(version.Platform))
+}
+
+// ExecuteURL returns the full URL of the execute endpoint.
+func (c *Client) ExecuteURL() string {
+	return c.BaseURL() + ExecutePath
+}
+
+// EventsURL returns the full URL of the events endpoint.
+func (c *Client) EventsURL() string {
+	return c.BaseURL() + EventsPath
+}
+
+// Execute runs a hive-cli command on the daemon and returns its Out.
+// A non-successful Out is returned together with an *Error. Execute always
+// sends a request, read commands go through query to share and cache them.
+// Without a deadline on ctx the command is bounded by the request timeout.
+func (c *Client) Execute(ctx context.Context, args ...string) (*Out, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, &Error{Command: strings.Join(args, " "), Message: err.Error()}
+	}
+	out, err := c.execute(ctx, args...)
+	release()
+	if err != nil {
+		c.report(ctx, err)
+	}
+	return out, err
+}
+
+// report passes err to the error handler.
+func (c *Client) report(ctx context.Context, err error) {
+	if ctx.Err() != nil || IsUnauthorized(err) {
+		return
+	}
+	c.mtx.RLock()
+	onError := c.onError
+	c.mtx.RUnlock()
+	if onError != nil {
+		onError(err)
+	}
+}
+
+func (c *Client) execute(ctx context.Context, args ...string) (*Out, error) {
+	cmd, err := NewCommand(c.Binary(), args...)
+	if err != nil {
+		return nil, &Error{Command: strings.Join(args, " "), Message: err.Error()}
+	}
+	command := cmd.String()
+	buf, err := cmd.Payload()
+	if err != nil {
+		return nil, &Error{Command: command, Message: err.Error()}
+	}
+	resp, err := c.post(ctx, c.ExecuteURL(), bytes.NewReader(buf))
+	if err != nil {
+		return nil, &Error{Command: command, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+	respBuf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &Error{Command: command, Status: resp.StatusCode, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &Error{
+			Command: command,
+			Status:  resp.StatusCode,
+			Message: http.StatusText(resp.StatusCode),
+			Details: strings.TrimSpace(string(respBuf)),
+		}
+	}
+	data := make(map[string]string)
+	err = json.Unmarshal(respBuf, &data)
+	if err != nil {
+		return nil, &Error{Command: command, Status: resp.StatusCode, Message: "invalid gateway response", Details: err.Error()}
+	}
+	var out Out
+	err = json.Unmarshal([]byte(data["val"]), &out)
+	if err != nil {
+		return nil, &Error{Command: command, Status: resp.StatusCode, Message: "invalid command output", Details: err.Error()}
+	}
+	if out.Status != StatusOK {
+		return &out, &Error{Command: command, Status: out.Status, Message: out.Message, Details: out.Details}
+	}
+	return &out, nil
+}
+
+// Events opens the daemon event stream. The caller must close the body of
+// the returned response.
+func (c *Client) Events(ctx context.Context) (*http.Response, error) {
+	resp, err := c.post(ctx, c.EventsURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &Error{Command: "events", Status: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+	return resp, nil
+}
+
+func (c *Client) post(ctx context.Context, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	c.mtx.RLock()
+	for key := range c.headers {
+		req.Header.Set(key, c.headers.Get(key))
+	}
+	unauthorized := c.unauthorized
+	c.mtx.RUnlock()
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && unauthorized != nil {
+		unauthorized()
+	}
+	return resp, nil
+}
+
+// IsUnauthorized reports whether err is a request rejected as unauthorized.
+func IsUnauthorized(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Status == http.StatusUnauthorized
+}
+
+// decode runs a read command and unmarshals its Out.Data into v.
+func (c *Client) decode(ctx context.Context, v interface{}, args ...string) error {
+	out, err := c.query(ctx, args...)
+	if err != nil {
+		return err
+	}
+	return c.decodeOut(ctx, out, v, args)
+}
+
+// decodeOut unmarshals the Out.Data of the command args into v.
+func (c *Client) decodeOut(ctx context.Context, out *Out, v interface{}, args []string) error {
+	err := out.Decode(v)
+	if err != nil {
+		err = &Error{Command: strings.Join(args, " "), Status: out.Status, Message: "invalid command data", Details: err.Error()}
+		c.report(ctx, err)
+		return err
+	}
+	return nil
+}
+
+// ID runs "id".
+func (c *Client) ID(ctx context.Context) (*ID, error) {
+	var id ID
+	if err := c.decode(ctx, &id, "id", "-j"); err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// Status runs "status".
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.decode(ctx, &status, "status", "-j"); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Config runs "config show".
+func (c *Client) Config(ctx context.Context) (*Config, error) {
+	var config Config
+	if err := c.decode(ctx, &config, "config", "show", "-j"); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Settings runs "settings -g".
+func (c *Client) Settings(ctx context.Context) (*Settings, error) {
+	var settings Settings
+	if err := c.decode(ctx, &settings, "settings", "-g", "-j"); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Earnings runs "earning -g".
+func (c *Client) Earnings(ctx context.Context) (*NetEarnings, error) {
+	var netEarnings NetEarnings
+	if err := c.decode(ctx, &netEarnings, "earning", "-g", "-j"); err != nil {
+		return nil, err
+	}
+	return &netEarnings, nil
+}
+
+// Bandwidth runs "stat bandwidth".
+func (c *Client) Bandwidth(ctx context.Context) (*Bandwidth, error) {
+	var bandwidth Bandwidth
+	if err := c.decode(ctx, &bandwidth, "stat", "bandwidth", "-j"); err != nil {
+		return nil, err
+	}
+	return &bandwidth, nil
+}
+
+// Peers runs "swarm peers" and returns the connected multiaddrs.
+func (c *Client) Peers(ctx context.Context) ([]string, error) {
+	var swarmPeers []string
+	if err := c.decode(ctx, &swarmPeers, "swarm", "peers", "-j"); err != nil {
+		return nil, err
+	}
+	return swarmPeers, nil
+}
+
+// Profile runs "profile".
+func (c *Client) Profile(ctx context.Context) (*Profile, error) {
+	var profile Profile
+	if err := c.decode(ctx, &profile, "profile", "-j"); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Version runs "version".
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.decode(ctx, &version, "version", "-j"); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// StorageLocation runs "config get-storage-location".
+func (c *Client) StorageLocation(ctx context.Context) (string, error) {
+	out, err := c.query(ctx, "config", "get-storage-location", "-j")
+	if err != nil {
+		return "", err
+	}
+	return out.Text(), nil
+}
+
+// VerifyPortForward runs "verify-port-forward" and returns the daemon's verdict.
+func (c *Client) VerifyPortForward(ctx context.Context) (string, error) {
+	out, err := c.Execute(ctx, "verify-port-forward")
+	if err != nil {
+		return "", err
+	}
+	return out.Text(), nil
+}
+
+// ModifyConfig runs "config modify <key> <value>".
+func (c *Client) ModifyConfig(ctx context.Context, key, value string) (*Out, error) {
+	defer c.Invalidate()
+	return c.Execute(ctx, "config", "modify", key, value)
+}
+
+// SaveSettings runs "settings" which persists the current settings.
+func (c *Client) SaveSettings(ctx context.Context) (*Out, error) {
+	defer c.Invalidate()
+	return c.Execute(ctx, "settings", "-j")
+}
+
+// SwarmConnect runs "swarm connect <multiaddr>".
+func (c *Client) SwarmConnect(ctx context.Context, addr string) (*Out, error) {
+	defer c.Invalidate()
+	return c.Execute(ctx, "swarm", "connect", addr, "-j")
+}
+
+// SwarmDisconnect runs "swarm disconnect <multiaddr>".
+func (c *Client) SwarmDisconnect(ctx context.Context, addr string) (*Out, error) {
+	defer c.Invalidate()
+	return c.Execute(ctx, "swarm", "disconnect", addr, "-j")
+}
+
+// Ping runs "ping <peer ID>". It always sends a request, a cached answer
+// would tell nothing about the peer.
+func (c *Client) Ping(ctx context.Context, peerID string) (*PingResponse, error) {
+	args := []string{"ping", peerID, "-j"}
+	out, err := c.Execute(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var ping PingResponse
+	if err := c.decodeOut(ctx, out, &ping, args); err != nil {
+		return nil, err
+	}
+	return &ping, nil
+}
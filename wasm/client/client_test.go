@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSameOrigin(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"http://localhost:4343", "http://localhost:4343/hive", true},
+		{"https://dash.example.com", "https://dash.example.com:443", true},
+		{"http://dash.example.com:80/hive", "http://DASH.example.com", true},
+		{"http://localhost:4343", "http://localhost:5001", false},
+		{"http://dash.example.com", "https://dash.example.com", false},
+		{"https://dash.example.com", "https://evil.example.com", false},
+		{"", "", false},
+		{"not a url", "http://localhost:4343", false},
+	} {
+		if got := SameOrigin(tc.a, tc.b); got != tc.want {
+			t.Errorf("SameOrigin(%q, %q): got %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestHeadersStayWithTheirOrigin(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-CSRF-Token"))
+		http.Error(w, "unknown command", http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-CSRF-Token"))
+		http.Error(w, "unknown command", http.StatusNotFound)
+	}))
+	t.Cleanup(other.Close)
+
+	c := New(srv.URL)
+	c.SetHeader("X-CSRF-Token", "secret")
+	ctx := context.Background()
+	c.Execute(ctx, "id", "-j")
+	// another path on the same server keeps the header
+	if err := c.SetBaseURL(srv.URL + "/hive"); err != nil {
+		t.Fatal(err)
+	}
+	c.Execute(ctx, "id", "-j")
+	if err := c.SetBaseURL(other.URL); err != nil {
+		t.Fatal(err)
+	}
+	c.Execute(ctx, "id", "-j")
+	if err := c.SetBaseURL(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	c.Execute(ctx, "id", "-j")
+	if want := []string{"secret", "secret", "", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultBinary is the hive-cli binary name used until it is configured or
+	// detected from the daemon's platform
+	DefaultBinary = "hive-cli.exe"
+
+	splicer = "%$#"
+)
+
+// Command is a single hive-cli invocation sent to the execute gateway. The
+// gateway splits the payload on the splicer and passes every piece to the
+// binary as one argument without going through a shell, so arguments may hold
+// whitespace as is. Arguments that contain the splicer or control characters
+// can't be represented and are rejected by NewCommand.
+type Command struct {
+	binary string
+	args   []string
+}
+
+// NewCommand validates binary and args and returns the Command running them.
+func NewCommand(binary string, args ...string) (*Command, error) {
+	if strings.TrimSpace(binary) == "" {
+		return nil, fmt.Errorf("missing hive-cli binary")
+	}
+	if err := ValidateArg(binary); err != nil {
+		return nil, fmt.Errorf("invalid hive-cli binary: %s", err.Error())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing hive-cli command")
+	}
+	for i, arg := range args {
+		if err := ValidateArg(arg); err != nil {
+			return nil, fmt.Errorf("invalid argument %d: %s", i+1, err.Error())
+		}
+	}
+	return &Command{binary: binary, args: append([]string(nil), args...)}, nil
+}
+
+// ValidateArg reports why arg can't be passed through the execute gateway.
+func ValidateArg(arg string) error {
+	if arg == "" {
+		return fmt.Errorf("empty argument")
+	}
+	if strings.Contains(arg, splicer) {
+		return fmt.Errorf("%q contains the reserved sequence %q", arg, splicer)
+	}
+	for _, r := range arg {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%q contains control characters", arg)
+		}
+	}
+	return nil
+}
+
+// BinaryFor returns the hive-cli binary name on a daemon platform as reported
+// by GOOS, e.g. "windows" or "linux".
+func BinaryFor(platform string) string {
+	if strings.EqualFold(platform, "windows") {
+		return "hive-cli.exe"
+	}
+	return "hive-cli"
+}
+
+// Args returns the hive-cli arguments, without the binary.
+func (c *Command) Args() []string {
+	return append([]string(nil), c.args...)
+}
+
+// String returns the command as typed in a shell, quoting arguments with
+// whitespace or quotes. It is meant for logs and errors.
+func (c *Command) String() string {
+	quoted := make([]string, len(c.args))
+	for i, arg := range c.args {
+		if strings.ContainsAny(arg, " \t\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Payload returns the execute gateway request body for the command.
+func (c *Command) Payload() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"val": strings.Join(append([]string{c.binary}, c.args...), splicer),
+	})
+}
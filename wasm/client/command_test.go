@@ -0,0 +1,73 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestNewCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		binary  string
+		args    []string
+		want    string
+		payload string
+		invalid bool
+	}{
+		{
+			name:    "plain",
+			binary:  "hive-cli",
+			args:    []string{"config", "modify", "SwarmPort", "4001"},
+			want:    "config modify SwarmPort 4001",
+			payload: `{"val":"hive-cli%$#config%$#modify%$#SwarmPort%$#4001"}`,
+		},
+		{
+			name:    "whitespace",
+			binary:  "hive-cli.exe",
+			args:    []string{"config", "modify", "DeviceName", "my node"},
+			want:    `config modify DeviceName "my node"`,
+			payload: `{"val":"hive-cli.exe%$#config%$#modify%$#DeviceName%$#my node"}`,
+		},
+		{name: "no binary", binary: " ", args: []string{"id"}, invalid: true},
+		{name: "no command", binary: "hive-cli", invalid: true},
+		{name: "empty argument", binary: "hive-cli", args: []string{"config", ""}, invalid: true},
+		{name: "splicer", binary: "hive-cli", args: []string{"id%$#rm"}, invalid: true},
+		{name: "control characters", binary: "hive-cli", args: []string{"id\n"}, invalid: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := NewCommand(tc.binary, tc.args...)
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("accepted %q %q", tc.binary, tc.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cmd.String(); got != tc.want {
+				t.Errorf("String: got %s, want %s", got, tc.want)
+			}
+			payload, err := cmd.Payload()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != tc.payload {
+				t.Errorf("Payload: got %s, want %s", payload, tc.payload)
+			}
+		})
+	}
+}
+
+func TestBinaryFor(t *testing.T) {
+	for platform, want := range map[string]string{
+		"windows": "hive-cli.exe",
+		"Windows": "hive-cli.exe",
+		"linux":   "hive-cli",
+		"darwin":  "hive-cli",
+		"":        "hive-cli",
+	} {
+		if got := BinaryFor(platform); got != want {
+			t.Errorf("%q: got %s, want %s", platform, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"fmt"
+)
+
+const (
+	// AllDevices selects the earnings of every device, it is the first option
+	// of the dashboard's device dropdown
+	AllDevices = "ALL DEVICES"
+	// RecentCycles is the number of billing cycles kept in CycleStats
+	RecentCycles = 6
+)
+
+// DeviceEarning is the total of a device over every billing cycle
+type DeviceEarning struct {
+	Device
+	Earning
+}
+
+// EarningsSummary aggregates NetEarnings for one device or AllDevices.
+// Cycles are in the order of BillingCycles, most recent first.
+type EarningsSummary struct {
+	Device        string                  `json:"device"`
+	BillingCycles []string                `json:"billingCycles"`
+	Cycles        []CycleStat             `json:"cycles"`
+	Recent        [RecentCycles]CycleStat `json:"recent"`
+	Devices       []DeviceEarning         `json:"devices"`
+	Total         Earning                 `json:"total"`
+	Average       Earning                 `json:"average"`
+}
+
+// Aggregate totals the earnings of device, a peer ID or AllDevices, per
+// billing cycle and per device, and fills CycleStats and DeviceTotal with
+// them. Devices missing the earnings of a cycle count as earning nothing in it.
+func (n *NetEarnings) Aggregate(device string) (*EarningsSummary, error) {
+	selected, err := n.selectDevices(device)
+	if err != nil {
+		return nil, err
+	}
+	summary := &EarningsSummary{
+		Device:        device,
+		BillingCycles: n.BillingCycles,
+		Cycles:        make([]CycleStat, len(n.BillingCycles)),
+		Devices:       make([]DeviceEarning, 0, len(selected)),
+	}
+	for i, cycle := range n.BillingCycles {
+		summary.Cycles[i].Cycle = cycle
+	}
+	for _, d := range selected {
+		total := DeviceEarning{Device: d}
+		for i, earning := range n.Data[d.PeerId] {
+			if i >= len(summary.Cycles) {
+				break
+			}
+			total.Earning = total.Earning.add(earning)
+			summary.Cycles[i].Earned += earning.Earned
+			summary.Cycles[i].Served += earning.Served
+			summary.Cycles[i].Downloaded += earning.Download
+		}
+		summary.Devices = append(summary.Devices, total)
+		summary.Total = summary.Total.add(total.Earning)
+	}
+	if len(summary.Cycles) > 0 {
+		count := float64(len(summary.Cycles))
+		summary.Average = Earning{
+			Earned:   summary.Total.Earned / count,
+			Served:   summary.Total.Served / count,
+			Download: summary.Total.Download / count,
+		}
+	}
+	copy(summary.Recent[:], summary.Cycles)
+	n.CycleStats = summary.Recent
+	n.DeviceTotal = summary.Total
+	return summary, nil
+}
+
+// EarningsRow is the earnings of a device in one billing cycle
+type EarningsRow struct {
+	Cycle      string  `json:"cycle"`
+	Device     string  `json:"device"`
+	PeerId     string  `json:"peerId"`
+	Earned     float64 `json:"earned"`
+	Served     float64 `json:"served"`
+	Downloaded float64 `json:"downloaded"`
+}
+
+// Rows returns the earnings of device, a peer ID or AllDevices, per billing
+// cycle from the cycle from up to the cycle to, oldest cycle first. Empty
+// bounds leave that end of the range open.
+func (n *NetEarnings) Rows(device, from, to string) ([]EarningsRow, error) {
+	selected, err := n.selectDevices(device)
+	if err != nil {
+		return nil, err
+	}
+	// billing cycles are most recent first
+	newest, oldest := 0, len(n.BillingCycles)-1
+	if to != "" {
+		newest = n.cycleIndex(to)
+		if newest < 0 {
+			return nil, fmt.Errorf("unknown billing cycle %q", to)
+		}
+	}
+	if from != "" {
+		oldest = n.cycleIndex(from)
+		if oldest < 0 {
+			return nil, fmt.Errorf("unknown billing cycle %q", from)
+		}
+	}
+	if oldest < newest {
+		return nil, fmt.Errorf("billing cycle %s is after %s", from, to)
+	}
+	var rows []EarningsRow
+	for i := oldest; i >= newest; i-- {
+		for _, d := range selected {
+			row := EarningsRow{Cycle: n.BillingCycles[i], Device: d.Name, PeerId: d.PeerId}
+			if earnings := n.Data[d.PeerId]; i < len(earnings) {
+				row.Earned = earnings[i].Earned
+				row.Served = earnings[i].Served
+				row.Downloaded = earnings[i].Download
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (n *NetEarnings) selectDevices(device string) ([]Device, error) {
+	if device == AllDevices {
+		return n.Devices, nil
+	}
+	var selected []Device
+	for _, d := range n.Devices {
+		if d.PeerId == device {
+			selected = append(selected, d)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("unknown device %q", device)
+	}
+	return selected, nil
+}
+
+func (n *NetEarnings) cycleIndex(cycle string) int {
+	for i, c := range n.BillingCycles {
+		if c == cycle {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e Earning) add(other Earning) Earning {
+	return Earning{
+		Earned:   e.Earned + other.Earned,
+		Served:   e.Served + other.Served,
+		Download: e.Download + other.Download,
+	}
+}
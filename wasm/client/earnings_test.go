@@ -0,0 +1,183 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadEarnings(t *testing.T) *NetEarnings {
+	t.Helper()
+	buf, err := ioutil.ReadFile(filepath.Join("testdata", "earnings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Out
+	err = json.Unmarshal(buf, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var netEarnings NetEarnings
+	err = out.Decode(&netEarnings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &netEarnings
+}
+
+func TestAggregate(t *testing.T) {
+	for _, tc := range []struct {
+		device  string
+		cycles  []CycleStat
+		devices []DeviceEarning
+		total   Earning
+		average Earning
+	}{
+		{
+			device: AllDevices,
+			cycles: []CycleStat{
+				{Cycle: "02-01-2021", Earned: 1.4, Served: 1073741824, Downloaded: 1049088},
+				{Cycle: "01-01-2021", Earned: 0.25, Served: 2048},
+			},
+			devices: []DeviceEarning{
+				{Device{Name: "hive-node", PeerId: "QmNode1"}, Earning{Earned: 1.75, Served: 1073743872, Download: 1048576}},
+				{Device{Name: "hive-laptop", PeerId: "QmNode2"}, Earning{Earned: -0.1, Download: 512}},
+			},
+			total:   Earning{Earned: 1.65, Served: 1073743872, Download: 1049088},
+			average: Earning{Earned: 0.825, Served: 536871936, Download: 524544},
+		},
+		{
+			device: "QmNode2",
+			cycles: []CycleStat{
+				{Cycle: "02-01-2021", Earned: -0.1, Downloaded: 512},
+				{Cycle: "01-01-2021"},
+			},
+			devices: []DeviceEarning{
+				{Device{Name: "hive-laptop", PeerId: "QmNode2"}, Earning{Earned: -0.1, Download: 512}},
+			},
+			total:   Earning{Earned: -0.1, Download: 512},
+			average: Earning{Earned: -0.05, Download: 256},
+		},
+	} {
+		t.Run(tc.device, func(t *testing.T) {
+			netEarnings := loadEarnings(t)
+			summary, err := netEarnings.Aggregate(tc.device)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(summary.Cycles, tc.cycles) {
+				t.Errorf("cycles: got %+v, want %+v", summary.Cycles, tc.cycles)
+			}
+			if !reflect.DeepEqual(summary.Devices, tc.devices) {
+				t.Errorf("devices: got %+v, want %+v", summary.Devices, tc.devices)
+			}
+			if !almostEqual(summary.Total, tc.total) || !almostEqual(summary.Average, tc.average) {
+				t.Errorf("got total %+v average %+v, want %+v %+v", summary.Total, summary.Average, tc.total, tc.average)
+			}
+			if netEarnings.DeviceTotal != summary.Total {
+				t.Errorf("DeviceTotal: got %+v", netEarnings.DeviceTotal)
+			}
+			want := [RecentCycles]CycleStat{tc.cycles[0], tc.cycles[1]}
+			if netEarnings.CycleStats != want || summary.Recent != want {
+				t.Errorf("CycleStats: got %+v", netEarnings.CycleStats)
+			}
+		})
+	}
+}
+
+func TestAggregateEdgeCases(t *testing.T) {
+	_, err := loadEarnings(t).Aggregate("QmUnknown")
+	if err == nil {
+		t.Error("aggregated an unknown device")
+	}
+
+	var empty NetEarnings
+	summary, err := empty.Aggregate(AllDevices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Cycles) != 0 || summary.Total != (Earning{}) || summary.Average != (Earning{}) {
+		t.Errorf("got %+v", summary)
+	}
+
+	// more earnings than cycles are ignored, the recent cycles are capped
+	netEarnings := NetEarnings{
+		BillingCycles: []string{"8", "7", "6", "5", "4", "3", "2"},
+		Devices:       []Device{{PeerId: "QmNode1"}},
+		Data:          map[string][]Earning{"QmNode1": make([]Earning, 9)},
+	}
+	for i := range netEarnings.Data["QmNode1"] {
+		netEarnings.Data["QmNode1"][i].Earned = 1
+	}
+	summary, err = netEarnings.Aggregate(AllDevices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Total.Earned != 7 || summary.Average.Earned != 1 {
+		t.Errorf("got total %+v average %+v", summary.Total, summary.Average)
+	}
+	if summary.Recent[0].Cycle != "8" || summary.Recent[RecentCycles-1].Cycle != "3" {
+		t.Errorf("recent: got %+v", summary.Recent)
+	}
+}
+
+func almostEqual(a, b Earning) bool {
+	near := func(x, y float64) bool {
+		d := x - y
+		return d < 1e-9 && d > -1e-9
+	}
+	return near(a.Earned, b.Earned) && near(a.Served, b.Served) && near(a.Download, b.Download)
+}
+
+func TestRows(t *testing.T) {
+	netEarnings := loadEarnings(t)
+	for _, tc := range []struct {
+		device, from, to string
+		want             []EarningsRow
+		invalid          bool
+	}{
+		{
+			device: AllDevices,
+			want: []EarningsRow{
+				{Cycle: "01-01-2021", Device: "hive-node", PeerId: "QmNode1", Earned: 0.25, Served: 2048},
+				{Cycle: "01-01-2021", Device: "hive-laptop", PeerId: "QmNode2"},
+				{Cycle: "02-01-2021", Device: "hive-node", PeerId: "QmNode1", Earned: 1.5, Served: 1073741824, Downloaded: 1048576},
+				{Cycle: "02-01-2021", Device: "hive-laptop", PeerId: "QmNode2", Earned: -0.1, Downloaded: 512},
+			},
+		},
+		{
+			device: "QmNode1",
+			from:   "02-01-2021",
+			want: []EarningsRow{
+				{Cycle: "02-01-2021", Device: "hive-node", PeerId: "QmNode1", Earned: 1.5, Served: 1073741824, Downloaded: 1048576},
+			},
+		},
+		{
+			device: "QmNode2",
+			to:     "01-01-2021",
+			want: []EarningsRow{
+				{Cycle: "01-01-2021", Device: "hive-laptop", PeerId: "QmNode2"},
+			},
+		},
+		{device: AllDevices, from: "02-01-2021", to: "01-01-2021", invalid: true},
+		{device: AllDevices, from: "31-12-2020", invalid: true},
+		{device: AllDevices, to: "03-01-2021", invalid: true},
+		{device: "QmUnknown", invalid: true},
+	} {
+		rows, err := netEarnings.Rows(tc.device, tc.from, tc.to)
+		if tc.invalid {
+			if err == nil {
+				t.Errorf("%s %s-%s: got %+v", tc.device, tc.from, tc.to, rows)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rows, tc.want) {
+			t.Errorf("%s %s-%s: got %+v, want %+v", tc.device, tc.from, tc.to, rows, tc.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is how long the result of a read command is reused
+	DefaultCacheTTL = 2 * time.Second
+	// DefaultMaxInFlight is the number of requests sent to the daemon at once
+	DefaultMaxInFlight = 4
+	// DefaultRequestTimeout bounds a command whose context has no deadline,
+	// so a hung daemon can't hold an in-flight slot forever
+	DefaultRequestTimeout = 30 * time.Second
+)
+
+// call is a read command in flight, shared by every caller asking for the
+// same command until it completes.
+type call struct {
+	done chan struct{}
+	out  *Out
+	err  error
+}
+
+type cacheEntry struct {
+	out     *Out
+	expires time.Time
+}
+
+// SetCacheTTL sets how long results of read commands are reused. Zero
+// disables caching, concurrent reads of the same command are still shared.
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.flightMtx.Lock()
+	defer c.flightMtx.Unlock()
+	c.cacheTTL = ttl
+	c.cache = make(map[string]cacheEntry)
+	c.generation++
+}
+
+// SetMaxInFlight limits the number of requests sent to the daemon at once.
+// Requests over the limit wait for a slot or for their context to end. It
+// must be called before the client is used.
+func (c *Client) SetMaxInFlight(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.slots = make(chan struct{}, n)
+}
+
+// SetRequestTimeout bounds commands whose context has no deadline. Zero
+// leaves them unbounded.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.requestTimeout = timeout
+}
+
+// withTimeout bounds ctx by the request timeout unless it has a deadline
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	c.mtx.RLock()
+	timeout := c.requestTimeout
+	c.mtx.RUnlock()
+	if _, found := ctx.Deadline(); found || timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Invalidate drops every cached result, e.g. after the daemon config changed.
+// Reads in flight are neither cached once they complete nor shared with
+// later callers.
+func (c *Client) Invalidate() {
+	c.flightMtx.Lock()
+	defer c.flightMtx.Unlock()
+	c.cache = make(map[string]cacheEntry)
+	c.calls = make(map[string]*call)
+	c.generation++
+}
+
+// acquire waits for an in-flight slot, the returned function releases it.
+func (c *Client) acquire(ctx context.Context) (func(), error) {
+	slots := c.slots
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// query runs a read command. Successful results are cached for the cache
+// TTL and callers asking for a command already in flight wait for its result
+// instead of sending another request. The shared request isn't canceled by
+// any single caller, each caller only stops waiting when its context ends.
+func (c *Client) query(ctx context.Context, args ...string) (*Out, error) {
+	key := strings.Join(args, splicer)
+	c.flightMtx.Lock()
+	if entry, found := c.cache[key]; found {
+		if time.Now().Before(entry.expires) {
+			c.flightMtx.Unlock()
+			return entry.out, nil
+		}
+		delete(c.cache, key)
+	}
+	cl, found := c.calls[key]
+	if !found {
+		cl = &call{done: make(chan struct{})}
+		c.calls[key] = cl
+		go c.run(key, cl, c.generation, args)
+	}
+	c.flightMtx.Unlock()
+	select {
+	case <-cl.done:
+		return cl.out, cl.err
+	case <-ctx.Done():
+		return nil, &Error{Command: strings.Join(args, " "), Message: ctx.Err().Error()}
+	}
+}
+
+// run sends a shared read, its result is cached only if the cache wasn't
+// invalidated since generation
+func (c *Client) run(key string, cl *call, generation uint64, args []string) {
+	cl.out, cl.err = c.Execute(context.Background(), args...)
+	c.flightMtx.Lock()
+	if c.calls[key] == cl {
+		delete(c.calls, key)
+	}
+	if cl.err == nil && c.cacheTTL > 0 && c.generation == generation {
+		c.cache[key] = cacheEntry{out: cl.out, expires: time.Now().Add(c.cacheTTL)}
+	}
+	c.flightMtx.Unlock()
+	close(cl.done)
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingDaemon answers every command with its arguments as text once
+// release lets it, and counts the requests and how many it served at once.
+type countingDaemon struct {
+	requests int32
+	inFlight int32
+	maxSeen  int32
+	release  chan struct{}
+}
+
+func newCountingDaemon(t *testing.T) (*countingDaemon, *Client) {
+	t.Helper()
+	d := &countingDaemon{release: make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&d.requests, 1)
+		n := atomic.AddInt32(&d.inFlight, 1)
+		defer atomic.AddInt32(&d.inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&d.maxSeen)
+			if n <= seen || atomic.CompareAndSwapInt32(&d.maxSeen, seen, n) {
+				break
+			}
+		}
+		payload := make(map[string]string)
+		json.NewDecoder(r.Body).Decode(&payload)
+		select {
+		case <-d.release:
+		case <-r.Context().Done():
+			return
+		}
+		args := strings.Split(payload["val"], splicer)[1:]
+		val, _ := json.Marshal(Out{Status: StatusOK, Data: strings.Join(args, " ")})
+		json.NewEncoder(w).Encode(map[string]string{"val": string(val)})
+	}))
+	t.Cleanup(srv.Close)
+	return d, New(srv.URL)
+}
+
+// open lets every request through
+func (d *countingDaemon) open() {
+	close(d.release)
+}
+
+func (d *countingDaemon) count() int {
+	return int(atomic.LoadInt32(&d.requests))
+}
+
+// waitRequests waits until the daemon received n requests
+func (d *countingDaemon) waitRequests(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for d.count() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d requests, want %d", d.count(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueryCoalesces(t *testing.T) {
+	d, c := newCountingDaemon(t)
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out, err := c.query(context.Background(), "status", "-j")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results[i] = out.Text()
+		}(i)
+	}
+	d.waitRequests(t, 1)
+	time.Sleep(20 * time.Millisecond)
+	d.open()
+	wg.Wait()
+	if d.count() != 1 {
+		t.Errorf("got %d requests for concurrent queries, want 1", d.count())
+	}
+	for _, result := range results {
+		if result != "status -j" {
+			t.Errorf("got %q", result)
+		}
+	}
+	// the result is cached
+	if _, err := c.query(context.Background(), "status", "-j"); err != nil || d.count() != 1 {
+		t.Errorf("cached query: got %d requests, %v", d.count(), err)
+	}
+	// other commands aren't
+	if _, err := c.query(context.Background(), "id", "-j"); err != nil || d.count() != 2 {
+		t.Errorf("other query: got %d requests, %v", d.count(), err)
+	}
+}
+
+func TestQueryCacheExpires(t *testing.T) {
+	d, c := newCountingDaemon(t)
+	d.open()
+	c.SetCacheTTL(50 * time.Millisecond)
+	ctx := context.Background()
+	c.query(ctx, "status", "-j")
+	c.query(ctx, "status", "-j")
+	if d.count() != 1 {
+		t.Errorf("got %d requests within the TTL, want 1", d.count())
+	}
+	time.Sleep(60 * time.Millisecond)
+	c.query(ctx, "status", "-j")
+	if d.count() != 2 {
+		t.Errorf("got %d requests after the TTL, want 2", d.count())
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	d, c := newCountingDaemon(t)
+	ctx := context.Background()
+	done := make(chan struct{})
+	go func() {
+		c.query(ctx, "swarm", "peers", "-j")
+		close(done)
+	}()
+	d.waitRequests(t, 1)
+	// a write lands while the read is in flight
+	c.Invalidate()
+	d.open()
+	<-done
+	c.query(ctx, "swarm", "peers", "-j")
+	if d.count() != 2 {
+		t.Errorf("got %d requests, the read in flight during Invalidate was cached", d.count())
+	}
+	c.query(ctx, "swarm", "peers", "-j")
+	if d.count() != 2 {
+		t.Errorf("got %d requests, the read after Invalidate wasn't cached", d.count())
+	}
+	c.Invalidate()
+	c.query(ctx, "swarm", "peers", "-j")
+	if d.count() != 3 {
+		t.Errorf("got %d requests after Invalidate, want 3", d.count())
+	}
+
+	// switching daemon or binary drops the cache too
+	if err := c.SetBaseURL(c.BaseURL()); err != nil {
+		t.Fatal(err)
+	}
+	c.query(ctx, "swarm", "peers", "-j")
+	if d.count() != 4 {
+		t.Errorf("got %d requests after SetBaseURL, want 4", d.count())
+	}
+	if err := c.SetBinary("hive-cli"); err != nil {
+		t.Fatal(err)
+	}
+	c.query(ctx, "swarm", "peers", "-j")
+	if d.count() != 5 {
+		t.Errorf("got %d requests after SetBinary, want 5", d.count())
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	d, c := newCountingDaemon(t)
+	c.SetMaxInFlight(1)
+	var wg sync.WaitGroup
+	for _, command := range []string{"status", "id", "version"} {
+		wg.Add(1)
+		go func(command string) {
+			defer wg.Done()
+			if _, err := c.query(context.Background(), command, "-j"); err != nil {
+				t.Error(err)
+			}
+		}(command)
+	}
+	d.waitRequests(t, 1)
+	time.Sleep(20 * time.Millisecond)
+	if d.count() != 1 {
+		t.Errorf("got %d requests at once, want 1", d.count())
+	}
+	d.open()
+	wg.Wait()
+	if d.count() != 3 || atomic.LoadInt32(&d.maxSeen) != 1 {
+		t.Errorf("got %d requests, %d at once, want 3 one at a time", d.count(), d.maxSeen)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	d, c := newCountingDaemon(t)
+	defer d.open()
+	c.SetMaxInFlight(1)
+	c.SetRequestTimeout(50 * time.Millisecond)
+	ctx := context.Background()
+	// a hung daemon releases the slot once the request times out
+	if _, err := c.query(ctx, "status", "-j"); err == nil {
+		t.Fatal("a hung request succeeded")
+	}
+	start := time.Now()
+	if _, err := c.Execute(ctx, "id", "-j"); err == nil {
+		t.Fatal("a hung request succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the second request waited %s for a slot", elapsed)
+	}
+	if d.count() != 2 {
+		t.Errorf("got %d requests, want 2", d.count())
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Multiaddr is a libp2p multiaddr as reported by `swarm peers` and `id`,
+// e.g. /ip4/198.51.100.7/udp/4001/quic/p2p/QmPeer. A relayed address
+// describes the relay, the peer is the one after p2p-circuit.
+type Multiaddr struct {
+	Raw       string `json:"raw"`
+	Transport string `json:"transport"`
+	IPVersion int    `json:"ipVersion,omitempty"`
+	Address   string `json:"address,omitempty"`
+	Port      int    `json:"port,omitempty"`
+	Relay     bool   `json:"relay"`
+	RelayID   string `json:"relayId,omitempty"`
+	PeerID    string `json:"peerId,omitempty"`
+}
+
+// multiaddrProtocols lists the protocols a multiaddr is known to hold and
+// whether they are followed by a value. Other protocols are skipped.
+var multiaddrProtocols = map[string]bool{
+	"ip4": true, "ip6": true, "dns": true, "dns4": true, "dns6": true, "dnsaddr": true,
+	"tcp": true, "udp": true, "p2p": true, "ipfs": true,
+	"ip6zone": true, "sni": true, "certhash": true,
+	"quic": false, "quic-v1": false, "ws": false, "wss": false, "tls": false,
+	"noise": false, "webtransport": false, "webrtc": false, "webrtc-direct": false,
+	"http": false, "https": false, "p2p-circuit": false,
+}
+
+// OtherTransport is the transport of peer addresses whose transport isn't
+// recognized
+const OtherTransport = "other"
+
+// ParseMultiaddr parses a multiaddr into its transport, address, port, relay
+// and peer ID. Unknown protocols are skipped, an address whose transport
+// isn't recognized still parses when it names its peer.
+func ParseMultiaddr(raw string) (Multiaddr, error) {
+	m := Multiaddr{Raw: raw}
+	parts := strings.Split(raw, "/")
+	if len(parts) < 2 || parts[0] != "" {
+		return m, fmt.Errorf("multiaddr %q doesn't start with /", raw)
+	}
+	parts = parts[1:]
+	for i := 0; i < len(parts); i++ {
+		protocol := parts[i]
+		if protocol == "" {
+			return m, fmt.Errorf("empty protocol in multiaddr %q", raw)
+		}
+		hasValue, known := multiaddrProtocols[protocol]
+		if !known {
+			// skip the value of the unknown protocol too, if one follows
+			if i+1 < len(parts) && parts[i+1] != "" {
+				if _, next := multiaddrProtocols[parts[i+1]]; !next {
+					i++
+				}
+			}
+			continue
+		}
+		value := ""
+		if hasValue {
+			i++
+			if i == len(parts) || parts[i] == "" {
+				return m, fmt.Errorf("missing %s value in multiaddr %q", protocol, raw)
+			}
+			value = parts[i]
+		}
+		switch protocol {
+		case "ip4", "dns4":
+			m.IPVersion, m.Address = 4, value
+		case "ip6", "dns6":
+			m.IPVersion, m.Address = 6, value
+		case "dns", "dnsaddr":
+			m.Address = value
+		case "tcp", "udp":
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 0 || port > 65535 {
+				return m, fmt.Errorf("invalid %s port %q in multiaddr %q", protocol, value, raw)
+			}
+			m.Port = port
+			m.Transport = protocol
+		case "quic", "quic-v1":
+			m.Transport = "quic"
+		case "ws", "wss", "webtransport", "webrtc", "webrtc-direct":
+			m.Transport = protocol
+		case "p2p", "ipfs":
+			m.PeerID = value
+		case "p2p-circuit":
+			m.Relay = true
+			m.RelayID, m.PeerID = m.PeerID, ""
+		}
+	}
+	if m.Transport == "" && m.Relay {
+		m.Transport = "p2p-circuit"
+	}
+	if m.Transport == "" {
+		if m.PeerID == "" {
+			return m, fmt.Errorf("no transport or peer ID in multiaddr %q", raw)
+		}
+		m.Transport = OtherTransport
+	}
+	return m, nil
+}
+
+// ParseMultiaddrs parses every multiaddr, the ones that don't parse are
+// returned with their errors
+func ParseMultiaddrs(raw []string) ([]Multiaddr, []error) {
+	addrs := make([]Multiaddr, 0, len(raw))
+	var errs []error
+	for _, value := range raw {
+		addr, err := ParseMultiaddr(value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, errs
+}
+
+// HostPort renders the address and port, with brackets around IPv6
+// addresses
+func (m Multiaddr) HostPort() string {
+	host := m.Address
+	if m.IPVersion == 6 {
+		host = "[" + host + "]"
+	}
+	if m.Port == 0 {
+		return host
+	}
+	return fmt.Sprintf("%s:%d", host, m.Port)
+}
+
+// PeerGroup holds the addresses a peer is connected over
+type PeerGroup struct {
+	PeerID     string         `json:"peerId"`
+	Addrs      []Multiaddr    `json:"addrs"`
+	Transports map[string]int `json:"transports"`
+	Relayed    bool           `json:"relayed"`
+}
+
+// GroupPeers groups addresses by peer ID, in order of first appearance
+func GroupPeers(addrs []Multiaddr) []PeerGroup {
+	var groups []PeerGroup
+	index := make(map[string]int)
+	for _, addr := range addrs {
+		i, found := index[addr.PeerID]
+		if !found {
+			i = len(groups)
+			index[addr.PeerID] = i
+			groups = append(groups, PeerGroup{PeerID: addr.PeerID, Transports: make(map[string]int)})
+		}
+		groups[i].Addrs = append(groups[i].Addrs, addr)
+		groups[i].Transports[addr.Transport]++
+		groups[i].Relayed = groups[i].Relayed || addr.Relay
+	}
+	return groups
+}
+
+// TransportCounts renders transport counts in order of name, e.g.
+// "quic 1, tcp 2"
+func TransportCounts(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s %d", name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
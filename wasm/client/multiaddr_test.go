@@ -0,0 +1,154 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMultiaddr(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want Multiaddr
+	}{
+		{
+			raw:  "/ip4/198.51.100.7/tcp/4001/p2p/QmPeer1",
+			want: Multiaddr{Transport: "tcp", IPVersion: 4, Address: "198.51.100.7", Port: 4001, PeerID: "QmPeer1"},
+		},
+		{
+			raw:  "/ip6/2001:db8::1/udp/4001/quic/p2p/QmPeer2",
+			want: Multiaddr{Transport: "quic", IPVersion: 6, Address: "2001:db8::1", Port: 4001, PeerID: "QmPeer2"},
+		},
+		{
+			raw:  "/ip4/203.0.113.24/udp/4001/quic-v1",
+			want: Multiaddr{Transport: "quic", IPVersion: 4, Address: "203.0.113.24", Port: 4001},
+		},
+		{
+			raw:  "/ip4/203.0.113.24/tcp/4002/ws",
+			want: Multiaddr{Transport: "ws", IPVersion: 4, Address: "203.0.113.24", Port: 4002},
+		},
+		{
+			raw:  "/dns4/relay.example.com/tcp/443/wss/ipfs/QmPeer3",
+			want: Multiaddr{Transport: "wss", IPVersion: 4, Address: "relay.example.com", Port: 443, PeerID: "QmPeer3"},
+		},
+		{
+			raw:  "/dnsaddr/bootstrap.example.com/tcp/4001",
+			want: Multiaddr{Transport: "tcp", Address: "bootstrap.example.com", Port: 4001},
+		},
+		{
+			raw:  "/ip4/198.51.100.9/tcp/4001/p2p/QmRelay/p2p-circuit/p2p/QmPeer4",
+			want: Multiaddr{Transport: "tcp", IPVersion: 4, Address: "198.51.100.9", Port: 4001, Relay: true, RelayID: "QmRelay", PeerID: "QmPeer4"},
+		},
+		{
+			raw:  "/p2p/QmRelay/p2p-circuit/p2p/QmPeer5",
+			want: Multiaddr{Transport: "p2p-circuit", Relay: true, RelayID: "QmRelay", PeerID: "QmPeer5"},
+		},
+		{
+			raw:  "/ip4/198.51.100.10/udp/4001/webrtc-direct/certhash/uEiDDq4_xNyDorZBH3TlGazyJdOWSwvo4PUo5YHFMrvDE8g/p2p/QmPeer6",
+			want: Multiaddr{Transport: "webrtc-direct", IPVersion: 4, Address: "198.51.100.10", Port: 4001, PeerID: "QmPeer6"},
+		},
+		{
+			raw:  "/ip6/2001:db8::2/udp/4001/quic-v1/webtransport/certhash/uEiAkH5a4DPGKUuOBjYw0CgwjvcJCJMD2K_1aluKR_tpevQ/certhash/uEiAfbZHLPJKCkBTmVc7bqTvSLPmaeKpuWAjgqvB1qOo7Bw/p2p/QmPeer7",
+			want: Multiaddr{Transport: "webtransport", IPVersion: 6, Address: "2001:db8::2", Port: 4001, PeerID: "QmPeer7"},
+		},
+		{
+			raw:  "/ip6/fe80::1/ip6zone/eth0/tcp/4001/tls/sni/peer.example.com/ws/p2p/QmPeer8",
+			want: Multiaddr{Transport: "ws", IPVersion: 6, Address: "fe80::1", Port: 4001, PeerID: "QmPeer8"},
+		},
+		{
+			raw:  "/ip4/198.51.100.11/sctp/5000/p2p/QmPeer9",
+			want: Multiaddr{Transport: OtherTransport, IPVersion: 4, Address: "198.51.100.11", PeerID: "QmPeer9"},
+		},
+		{
+			raw:  "/p2p/QmPeer10",
+			want: Multiaddr{Transport: OtherTransport, PeerID: "QmPeer10"},
+		},
+	} {
+		got, err := ParseMultiaddr(tc.raw)
+		if err != nil {
+			t.Errorf("%s: %s", tc.raw, err.Error())
+			continue
+		}
+		tc.want.Raw = tc.raw
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestParseMultiaddrErrors(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"ip4/1.2.3.4/tcp/1",
+		"/ip4/1.2.3.4",
+		"/ip4/1.2.3.4/tcp",
+		"/ip4/1.2.3.4/tcp/",
+		"/ip4/1.2.3.4/tcp/http",
+		"/ip4/1.2.3.4/tcp/65536",
+		"/ip4/1.2.3.4/sctp/5000",
+		"/ip4/1.2.3.4//tcp/1",
+	} {
+		if got, err := ParseMultiaddr(raw); err == nil {
+			t.Errorf("%q: parsed as %+v", raw, got)
+		}
+	}
+	addrs, errs := ParseMultiaddrs([]string{"/ip4/1.2.3.4/tcp/1", "/bogus", "/ip6/::1/udp/2/quic"})
+	if len(addrs) != 2 || len(errs) != 1 {
+		t.Errorf("got %d addresses and %d errors", len(addrs), len(errs))
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	for _, tc := range []struct {
+		raw, want string
+	}{
+		{"/ip4/1.2.3.4/tcp/4001", "1.2.3.4:4001"},
+		{"/ip6/::1/tcp/4001", "[::1]:4001"},
+		{"/dns/example.com/tcp/443/wss", "example.com:443"},
+		{"/p2p/QmRelay/p2p-circuit/p2p/QmPeer", ""},
+	} {
+		addr, err := ParseMultiaddr(tc.raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := addr.HostPort(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestGroupPeers(t *testing.T) {
+	addrs, errs := ParseMultiaddrs([]string{
+		"/ip4/198.51.100.7/tcp/4001/p2p/QmPeer1",
+		"/ip4/198.51.100.8/tcp/4001/p2p/QmPeer2",
+		"/ip4/198.51.100.7/udp/4001/quic/p2p/QmPeer1",
+		"/ip6/2001:db8::7/tcp/4001/p2p/QmPeer1",
+		"/ip4/198.51.100.9/tcp/4001/p2p/QmRelay/p2p-circuit/p2p/QmPeer2",
+	})
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	groups := GroupPeers(addrs)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups", len(groups))
+	}
+	for i, tc := range []struct {
+		peerID     string
+		addrs      int
+		transports string
+		relayed    bool
+	}{
+		{"QmPeer1", 3, "quic 1, tcp 2", false},
+		{"QmPeer2", 2, "tcp 2", true},
+	} {
+		group := groups[i]
+		if group.PeerID != tc.peerID || len(group.Addrs) != tc.addrs || TransportCounts(group.Transports) != tc.transports || group.Relayed != tc.relayed {
+			t.Errorf("%d: got %s with %d addresses over %s relayed %v", i, group.PeerID, len(group.Addrs), TransportCounts(group.Transports), group.Relayed)
+		}
+	}
+	if got := GroupPeers(nil); len(got) != 0 {
+		t.Errorf("grouped nothing into %v", got)
+	}
+	if got := TransportCounts(nil); got != "" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -1,8 +1,9 @@
-package main
+package client
 
 import (
 	"encoding/json"
 	store "github.com/StreamSpace/ss-store"
+	"strconv"
 	"time"
 )
 
@@ -13,6 +14,19 @@ type Event struct {
 	}
 }
 
+func (e *Event) GetNamespace() string {
+	return "Event"
+}
+func (e *Event) GetId() string {
+	return e.Result.Topic
+}
+func (e *Event) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+func (e *Event) Unmarshal(val []byte) error {
+	return json.Unmarshal(val, e)
+}
+
 type Out struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
@@ -20,8 +34,25 @@ type Out struct {
 	Details string      `json:"details,omitempty"`
 }
 
+// Decode unmarshals the command data into v.
+func (o *Out) Decode(v interface{}) error {
+	val, err := json.Marshal(o.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, v)
+}
+
+// Text returns the command data when it is plain text, otherwise the message.
+func (o *Out) Text() string {
+	if s, ok := o.Data.(string); ok {
+		return s
+	}
+	return o.Message
+}
+
 type ID struct {
-	PeerID    string   `json:"id,omitempty"`
+	PeerID    string   `json:"id,omitempty" dom:"PeerID"`
 	Publickey string   `json:"PublicKey,omitempty"`
 	Addresses []string `json:"Addresses,omitempty"`
 }
@@ -32,29 +63,43 @@ type SwarmPeers struct {
 
 type Profile struct {
 	Id              string `json:"_id,omitempty"`
-	Email           string `json:"email,omitempty"`
+	Email           string `json:"email,omitempty" dom:"Email"`
 	FirstName       string `json:"firstName,omitempty"`
 	PhoneNumber     string `json:"phoneNumber,omitempty"`
-	Role            string `json:"role,omitempty"`
+	Role            string `json:"role,omitempty" dom:"Role"`
 	MFAType         int64  `json:"mfaType,omitempty"`
 	IsMFAEnabled    bool   `json:"isMfaEnabled,omitempty"`
 	LastLoginAt     string `json:"lastLoginAt,omitempty"`
-	IsEmailVerified bool   `json:"isEmailVerified",omitempty"`
+	IsEmailVerified bool   `json:"isEmailVerified,omitempty"`
 }
 
 type Bandwidth struct {
-	Incoming float64 `json:"RateIn,omitempty"`
-	Outgoing float64 `json:"RateOut,omitempty"`
+	Incoming float64 `json:"RateIn,omitempty" dom:"Incoming,format=bytes"`
+	Outgoing float64 `json:"RateOut,omitempty" dom:"Outgoing,format=bytes"`
 	Time     int64   `json:"Time,omitempty"`
 }
 
+func (b *Bandwidth) GetNamespace() string {
+	return "Bandwidth"
+}
+func (b *Bandwidth) GetId() string {
+	return strconv.FormatInt(b.Time, 10)
+}
+func (b *Bandwidth) Marshal() ([]byte, error) {
+	return json.Marshal(b)
+}
+func (b *Bandwidth) Unmarshal(val []byte) error {
+	return json.Unmarshal(val, b)
+}
+
 type Version struct {
-	AppVersion    string `json:"appversion,omitempty"`
+	AppVersion    string `json:"appversion,omitempty" dom:"Version"`
 	CurrentCommit string `json:"currentcommit,omitempty"`
 	Debug         string `json:"debug,omitempty"`
 	Environment   string `json:"environment,omitempty"`
 	Epoch         string `json:"epoch,omitempty"`
 	CycleDuration string `json:"cycleduration,omitempty"`
+	Platform      string `json:"platform,omitempty"`
 }
 
 type AuthResponse struct {
@@ -166,8 +211,8 @@ func (b *Balance) Unmarshal(val []byte) error {
 type BCNBalance struct {
 	Owned           float64 `json:"owned"`
 	Owe             float64 `json:"owe"`
-	BytesServed     float64   `json:"served"`
-	BytesDownloaded float64   `json:"downloaded"`
+	BytesServed     float64 `json:"served" dom:"CycleServed,format=bytes"`
+	BytesDownloaded float64 `json:"downloaded" dom:"CycleDownloaded,format=bytes"`
 	Id              string  `json:"id"`
 }
 
@@ -193,8 +238,8 @@ type Settings struct {
 	Name                           string  `json:"name,omitempty"`
 	Location                       string  `json:"location,omitempty"`
 	IPAddress                      string  `json:"ipAddress,omitempty"`
-	MaxStorage                     float64 `json:"maxStorage,omitempty"`
-	UsedStorage                    float64 `json:"usedStorage"`
+	MaxStorage                     float64 `json:"maxStorage,omitempty" dom:"MaxStorage,format=gb"`
+	UsedStorage                    float64 `json:"usedStorage" dom:"UsedStorage,format=gb"`
 	PinnedStorage                  float64 `json:"pinned_storage"`
 	HiveStorage                    float64 `json:"hive_storage"`
 	PeerID                         string  `json:"peerId,omitempty"`
@@ -205,7 +250,7 @@ type Settings struct {
 	DesktopApplicationAutoStart    bool    `json:"isAutoStartEnabled"`
 	DNS                            string  `json:"dnsAddress"`
 	Role                           string  `json:"role,omitempty"`
-	FreeDiskSpace				   float64 `json:"freeDiskSpace,omitempty"`
+	FreeDiskSpace                  float64 `json:"freeDiskSpace,omitempty"`
 }
 
 func (b *Settings) GetNamespace() string {
@@ -240,46 +285,46 @@ type TaskWithProgressStatus struct {
 }
 
 type ServerStatus struct {
-	Rpc   string `json:"Rpc"`
-	Http  string `json:"Http"`
-	Proxy string `json:"Proxy"`
+	Rpc   string `json:"Rpc" dom:"Rpc,empty=Not Running"`
+	Http  string `json:"Http" dom:"Http,empty=Not Running"`
+	Proxy string `json:"Proxy" dom:"Proxy,empty=Not Running"`
 }
 
 type Config struct {
-	APIPort                        string   `json:"APIPort, omitempty"`
-	AutoGC                         bool     `json:"AutoGC, omitempty"`
-	Bootstraps                     []string `json:"Bootstraps, omitempty"`
-	DNS4                           string   `json:"DNS4, omitempty"`
-	DataStore                      string   `json:"DataStore, omitempty"`
-	DesktopApplicationAutoStart    bool     `json:"DesktopApplicationAutoStart, omitempty"`
-	DesktopApplicationNotification bool     `json:"DesktopApplicationNotification, omitempty"`
-	DeviceName                     string   `json:"DeviceName, omitempty"`
-	EnableDynamicDNS               bool     `json:"EnableDynamicDNS, omitempty"`
-	EnableFileShare                bool     `json:"EnableFileShare, omitempty"`
-	EnableHop                      bool     `json:"EnableHop, omitempty"`
-	GCPeriod                       string   `json:"GCPeriod, omitempty"`
-	GatewayPort                    string   `json:"GatewayPort, omitempty"`
-	IP4                            string   `json:"IP4, omitempty"`
-	IP6                            string   `json:"IP6, omitempty"`
-	identity                       Identity `json:"Identity, omitempty"`
-	MaxPeers                       int      `json:"MaxPeers, omitempty"`
-	ProxyPort                      string   `json:"ProxyPort, omitempty"`
-	ReproviderInterval             string   `json:"ReproviderInterval, omitempty"`
-	Storage                        float64   `json:"Storage, omitempty"`
-	StorageGCWatermark             int      `json:"StorageGCWatermark, omitempty"`
-	Store                          string   `json:"Store, omitempty"`
-	SwarmPort                      string   `json:"SwarmPort, omitempty"`
-	WebsocketPort                  string   `json:"WebsocketPort, omitempty"`
+	APIPort                        string   `json:"APIPort,omitempty"`
+	AutoGC                         bool     `json:"AutoGC,omitempty"`
+	Bootstraps                     []string `json:"Bootstraps,omitempty"`
+	DNS4                           string   `json:"DNS4,omitempty"`
+	DataStore                      string   `json:"DataStore,omitempty"`
+	DesktopApplicationAutoStart    bool     `json:"DesktopApplicationAutoStart,omitempty"`
+	DesktopApplicationNotification bool     `json:"DesktopApplicationNotification,omitempty"`
+	DeviceName                     string   `json:"DeviceName,omitempty"`
+	EnableDynamicDNS               bool     `json:"EnableDynamicDNS,omitempty"`
+	EnableFileShare                bool     `json:"EnableFileShare,omitempty"`
+	EnableHop                      bool     `json:"EnableHop,omitempty"`
+	GCPeriod                       string   `json:"GCPeriod,omitempty"`
+	GatewayPort                    string   `json:"GatewayPort,omitempty"`
+	IP4                            string   `json:"IP4,omitempty"`
+	IP6                            string   `json:"IP6,omitempty"`
+	identity                       Identity
+	MaxPeers                       int     `json:"MaxPeers,omitempty"`
+	ProxyPort                      string  `json:"ProxyPort,omitempty"`
+	ReproviderInterval             string  `json:"ReproviderInterval,omitempty"`
+	Storage                        float64 `json:"Storage,omitempty"`
+	StorageGCWatermark             int     `json:"StorageGCWatermark,omitempty"`
+	Store                          string  `json:"Store,omitempty"`
+	SwarmPort                      string  `json:"SwarmPort,omitempty"`
+	WebsocketPort                  string  `json:"WebsocketPort,omitempty"`
 }
 
 type Identity struct {
-	PeerId  string `json:"PeerId, omitempty"`
-	PrivKey string `json:"PrivKey, omitempty"`
+	PeerId  string `json:"PeerId,omitempty"`
+	PrivKey string `json:"PrivKey,omitempty"`
 }
 
 type Status struct {
-	LoggedIn              bool
-	DaemonRunning         bool
+	LoggedIn              bool `dom:"LoggedIn,format=bool:LoggedIn/LoggedOut"`
+	DaemonRunning         bool `dom:"DaemonRunning,format=bool:ONLINE/OFFLINE"`
 	TotalUptimePercentage UptimePercentage
 	SessionStartTime      int64
 	TaskManagerStatus     []TaskStatus
@@ -287,7 +332,7 @@ type Status struct {
 }
 type UptimePercentage struct {
 	Status               bool
-	Percentage           float64
+	Percentage           float64 `dom:"percentageNumber,format=percent"`
 	SecondsFromInception int64
 	Timestamp            int64
 }
@@ -335,7 +380,7 @@ type NetEarnings struct {
 	BillingCycles []string             `json:"billingCycles"`
 	Devices       []Device             `json:"devices"`
 	Data          map[string][]Earning `json:"earnings"`
-	CycleStats    [6]CycleStat
+	CycleStats    [RecentCycles]CycleStat
 	DeviceTotal   Earning
 }
 
@@ -358,10 +403,10 @@ func (n *NetEarnings) Unmarshal(val []byte) error {
 }
 
 type CycleStat struct {
-	Cycle      string
-	Earned     float64
-	Downloaded float64
-	Served     float64
+	Cycle      string  `json:"cycle"`
+	Earned     float64 `json:"earned"`
+	Downloaded float64 `json:"downloaded"`
+	Served     float64 `json:"served"`
 }
 type FileObj struct {
 	Filename               string `json:"filename"`
@@ -452,6 +497,11 @@ func (v *Version) Unmarshal(val []byte) error {
 	return nil
 }
 
+// PingResponse is the answer of "ping". Time is the round trip measured by
+// the daemon, zero when it doesn't report one, and Text tells why a ping
+// failed.
 type PingResponse struct {
-	Success bool `json:"success"`
+	Success bool          `json:"success"`
+	Time    time.Duration `json:"time,omitempty"`
+	Text    string        `json:"text,omitempty"`
 }
@@ -0,0 +1,362 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureDaemon answers every command with the Out stored in
+// testdata/<fixture>.json, by the command as typed without the binary.
+func fixtureDaemon(t *testing.T, fixtures map[string]string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := make(map[string]string)
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args := strings.Split(payload["val"], splicer)
+		fixture, found := fixtures[strings.Join(args[1:], " ")]
+		if !found {
+			http.Error(w, "unknown command", http.StatusNotFound)
+			return
+		}
+		buf, err := ioutil.ReadFile(filepath.Join("testdata", fixture+".json"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"val": string(buf)})
+	}))
+	t.Cleanup(srv.Close)
+	c := New(srv.URL)
+	c.SetCacheTTL(0)
+	return c
+}
+
+func TestDecoders(t *testing.T) {
+	c := fixtureDaemon(t, map[string]string{
+		"id -j":                          "id",
+		"status -j":                      "status",
+		"config show -j":                 "config",
+		"settings -g -j":                 "settings",
+		"earning -g -j":                  "earnings",
+		"stat bandwidth -j":              "bandwidth",
+		"swarm peers -j":                 "peers",
+		"profile -j":                     "profile",
+		"version -j":                     "version",
+		"config get-storage-location -j": "storage_location",
+		"verify-port-forward":            "failed",
+		"ping QmPeer1 -j":                "ping",
+	})
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		call func() (interface{}, error)
+		want interface{}
+	}{
+		{
+			name: "ID",
+			call: func() (interface{}, error) { return c.ID(ctx) },
+			want: &ID{
+				PeerID:    "QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N",
+				Publickey: "CAASpgIwggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAwggEKAoIBAQC",
+				Addresses: []string{
+					"/ip4/127.0.0.1/tcp/4001",
+					"/ip4/203.0.113.24/tcp/4001",
+					"/ip4/203.0.113.24/tcp/4002/ws",
+					"/ip6/::1/tcp/4001",
+				},
+			},
+		},
+		{
+			name: "Status",
+			call: func() (interface{}, error) { return c.Status(ctx) },
+			want: &Status{
+				LoggedIn:      true,
+				DaemonRunning: true,
+				TotalUptimePercentage: UptimePercentage{
+					Status:               true,
+					Percentage:           98.25,
+					SecondsFromInception: 2592000,
+					Timestamp:            1609545600,
+				},
+				SessionStartTime: 1609459200,
+				TaskManagerStatus: []TaskStatus{
+					{Id: 1, Name: "Idle", Status: "Running"},
+					{Id: 2, Name: "Replication", Status: "Running", AdditionalStatus: "42%"},
+				},
+				ServerDetails: ServerStatus{Rpc: "Running", Http: "Running"},
+			},
+		},
+		{
+			name: "Config",
+			call: func() (interface{}, error) { return c.Config(ctx) },
+			want: &Config{
+				APIPort:         "4343",
+				AutoGC:          true,
+				Bootstraps:      []string{"/dns4/bootstrap.example.com/tcp/4001/p2p/QmBootstrap"},
+				DeviceName:      "hive-node",
+				EnableFileShare: true,
+				GCPeriod:        "1h",
+				Storage:         4398046511104,
+				SwarmPort:       "4001",
+				WebsocketPort:   "4002",
+			},
+		},
+		{
+			name: "Settings",
+			call: func() (interface{}, error) { return c.Settings(ctx) },
+			want: &Settings{
+				NodeIndex:                      3,
+				DeviceID:                       "device-1",
+				Name:                           "hive-node",
+				MaxStorage:                     4096,
+				UsedStorage:                    1234.5678,
+				PinnedStorage:                  12,
+				HiveStorage:                    1222.5678,
+				PeerID:                         "QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N",
+				IsReachable:                    true,
+				DesktopApplicationNotification: true,
+				DNS:                            "node.example.com",
+				Role:                           "Miner",
+				FreeDiskSpace:                  5497558138880,
+			},
+		},
+		{
+			name: "Earnings",
+			call: func() (interface{}, error) { return c.Earnings(ctx) },
+			want: &NetEarnings{
+				BillingCycles: []string{"02-01-2021", "01-01-2021"},
+				Devices: []Device{
+					{Name: "hive-node", PeerId: "QmNode1"},
+					{Name: "hive-laptop", PeerId: "QmNode2"},
+				},
+				Data: map[string][]Earning{
+					"QmNode1": {
+						{Earned: 1.5, Served: 1073741824, Download: 1048576},
+						{Earned: 0.25, Served: 2048},
+					},
+					"QmNode2": {
+						{Earned: -0.1, Download: 512},
+					},
+				},
+			},
+		},
+		{
+			name: "Bandwidth",
+			call: func() (interface{}, error) { return c.Bandwidth(ctx) },
+			want: &Bandwidth{Incoming: 1572864.5, Time: 1609459200},
+		},
+		{
+			name: "Peers",
+			call: func() (interface{}, error) { return c.Peers(ctx) },
+			want: []string{
+				"/ip4/198.51.100.7/tcp/4001/p2p/QmPeer1",
+				"/ip6/2001:db8::1/tcp/4001/p2p/QmPeer2",
+			},
+		},
+		{
+			name: "Profile",
+			call: func() (interface{}, error) { return c.Profile(ctx) },
+			want: &Profile{
+				Id:              "5f8d0d55b54764421b7156c2",
+				Email:           "operator@example.com",
+				FirstName:       "Hive",
+				Role:            "Miner",
+				IsEmailVerified: true,
+				LastLoginAt:     "2021-01-01T00:00:00Z",
+			},
+		},
+		{
+			name: "Version",
+			call: func() (interface{}, error) { return c.Version(ctx) },
+			want: &Version{
+				AppVersion:    "v0.2.14",
+				CurrentCommit: "3f2c1a9",
+				Environment:   "production",
+				Epoch:         "1609459200",
+				CycleDuration: "24h",
+				Platform:      "windows",
+			},
+		},
+		{
+			name: "StorageLocation",
+			call: func() (interface{}, error) { return c.StorageLocation(ctx) },
+			want: "/home/hive/.hive/store",
+		},
+		{
+			name: "Ping",
+			call: func() (interface{}, error) { return c.Ping(ctx, "QmPeer1") },
+			want: &PingResponse{Success: true, Time: 23500 * time.Microsecond},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	c := fixtureDaemon(t, map[string]string{
+		"status -j":           "malformed_status",
+		"verify-port-forward": "failed",
+	})
+	var reported []error
+	c.SetErrorHandler(func(err error) { reported = append(reported, err) })
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name    string
+		call    func() error
+		status  int
+		message string
+	}{
+		{
+			name:    "malformed data",
+			call:    func() error { _, err := c.Status(ctx); return err },
+			status:  StatusOK,
+			message: "invalid command data",
+		},
+		{
+			name:    "failed command",
+			call:    func() error { _, err := c.VerifyPortForward(ctx); return err },
+			status:  500,
+			message: "daemon is not running",
+		},
+		{
+			name:    "unknown command",
+			call:    func() error { _, err := c.Profile(ctx); return err },
+			status:  http.StatusNotFound,
+			message: "Not Found",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("got %v, want an *Error", err)
+			}
+			if e.Status != tc.status || e.Message != tc.message {
+				t.Errorf("got status %d message %q, want %d %q", e.Status, e.Message, tc.status, tc.message)
+			}
+		})
+	}
+	if len(reported) != 3 {
+		t.Errorf("got %d errors reported, want 3", len(reported))
+	}
+}
+
+func TestSwarmActions(t *testing.T) {
+	addr := "/ip4/198.51.100.7/tcp/4001/p2p/QmPeer1"
+	c := fixtureDaemon(t, map[string]string{
+		"swarm connect " + addr + " -j":                              "connect",
+		"swarm disconnect /ip4/198.51.100.9/tcp/4001/p2p/QmPeer9 -j": "disconnect_failed",
+	})
+	ctx := context.Background()
+	out, err := c.SwarmConnect(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Message != "connect QmPeer1 success" {
+		t.Errorf("connect: got %q", out.Message)
+	}
+	_, err = c.SwarmDisconnect(ctx, "/ip4/198.51.100.9/tcp/4001/p2p/QmPeer9")
+	if e, ok := err.(*Error); !ok || e.Status != 400 || e.Message != "not connected" {
+		t.Errorf("disconnect: got %v", err)
+	}
+	if _, err := c.SwarmConnect(ctx, "/ip4/198.51.100.7/tcp/4001\n"); err == nil {
+		t.Error("connected to an address holding a newline")
+	}
+}
+
+func TestStoreItems(t *testing.T) {
+	settlementDate := time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		raw  string
+		item interface {
+			Unmarshal([]byte) error
+		}
+		want interface{}
+	}{
+		{
+			name: "Settlement",
+			raw:  `{"bcn": 18629, "settlementDate": "2021-01-02T00:00:00Z", "dataRatePerByte": 1.25e-10}`,
+			item: &Settlement{},
+			want: &Settlement{Cycle: 18629, Date: settlementDate, Rate: 1.25e-10},
+		},
+		{
+			name: "BCNBalance",
+			raw:  `{"owned": 12.5, "owe": -1.25, "served": 5497558138880, "downloaded": 0, "id": "QmNode1"}`,
+			item: &BCNBalance{},
+			want: &BCNBalance{Owned: 12.5, Owe: -1.25, BytesServed: 5497558138880, Id: "QmNode1"},
+		},
+		{
+			name: "Balance",
+			raw:  `{"userId": "user-1", "balance": -0.5}`,
+			item: &Balance{},
+			want: &Balance{UserId: "user-1", Balance: -0.5},
+		},
+		{
+			name: "Bandwidth",
+			raw:  `{"RateIn": 1572864.5, "RateOut": 0, "Time": 1609459200}`,
+			item: &Bandwidth{},
+			want: &Bandwidth{Incoming: 1572864.5, Time: 1609459200},
+		},
+		{
+			name: "Event",
+			raw:  `{"Result": {"topic": "balance", "val": "{\"status\":200,\"data\":12.5}"}}`,
+			item: &Event{},
+			want: func() *Event {
+				event := &Event{}
+				event.Result.Topic = "balance"
+				event.Result.Val = `{"status":200,"data":12.5}`
+				return event
+			}(),
+		},
+		{
+			name: "Version",
+			raw:  `v0.2.14`,
+			item: &Version{},
+			want: &Version{AppVersion: "v0.2.14"},
+		},
+		{
+			name: "DeviceOwner",
+			raw:  `operator@example.com`,
+			item: &DeviceOwner{},
+			want: &DeviceOwner{Email: "operator@example.com"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.item.Unmarshal([]byte(tc.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.item, tc.want) {
+				t.Errorf("got %+v, want %+v", tc.item, tc.want)
+			}
+		})
+	}
+	for _, item := range []interface {
+		Unmarshal([]byte) error
+	}{&Settlement{}, &BCNBalance{}, &Balance{}, &Settings{}, &NetEarnings{}, &UptimePercentage{}, &Bandwidth{}, &Event{}} {
+		if err := item.Unmarshal([]byte(`{"`)); err == nil {
+			t.Errorf("%T accepted malformed JSON", item)
+		}
+	}
+}
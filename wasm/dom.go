@@ -0,0 +1,66 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+// DOM is the part of the page the dashboard reads and writes. The browser
+// implementation lives in dom_js.go, tests use an in-memory fake.
+type DOM interface {
+	// Property returns a property of the element with id, found is false when
+	// the element isn't on the page.
+	Property(id, name string) (value string, found bool)
+	// SetProperty sets a property of the element with id and reports whether
+	// the element is on the page.
+	SetProperty(id, name, value string) bool
+	// AppendChild appends a new element with the tag and properties to the
+	// element with id and reports whether the parent is on the page.
+	AppendChild(id, tag string, properties map[string]string) bool
+	// StorageItem returns a value persisted in the browser's localStorage.
+	StorageItem(key string) (value string, found bool)
+	// SetStorageItem persists a value in the browser's localStorage.
+	SetStorageItem(key, value string)
+	// RemoveStorageItem removes a value from the browser's localStorage.
+	RemoveStorageItem(key string)
+}
+
+// dom is the page the dashboard renders into, set by main on js and by tests
+var dom DOM
+
+func SetDisplay(Id string, Attr string, value string) {
+	if !dom.SetProperty(Id, Attr, value) {
+		log.Warn("Unable to get output area in: ", Id)
+	}
+}
+
+// SetText sets the text of an element if it is on the page
+func SetText(Id string, value string) {
+	if !dom.SetProperty(Id, "textContent", value) {
+		log.Debugf("No element %s on this page", Id)
+	}
+}
+
+func SetMultipleDisplay(Id string, Attributes map[string]string) {
+	for attr, value := range Attributes {
+		if !dom.SetProperty(Id, attr, value) {
+			log.Warn("Unable to get output area in: ", Id)
+			return
+		}
+	}
+}
+
+func GetValue(Id string, Attr string) string {
+	value, found := dom.Property(Id, Attr)
+	if !found {
+		log.Warn("Unable to get output area in: ", Id)
+		return ""
+	}
+	return value
+}
+
+func CreateElement(Id string, element string, Attr string, value string) {
+	properties := make(map[string]string)
+	if value != "" {
+		properties[Attr] = value
+	}
+	if !dom.AppendChild(Id, element, properties) {
+		log.Warn("Unable to get output area in: ", Id)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sync"
+)
+
+// FakeDOM is an in-memory DOM for tests. Only the elements it was created
+// with are on the page, like ids missing from the HTML of a page.
+type FakeDOM struct {
+	mtx      sync.Mutex
+	elements map[string]*fakeElement
+	storage  map[string]string
+}
+
+type fakeElement struct {
+	tag        string
+	properties map[string]string
+	children   []*fakeElement
+}
+
+func NewFakeDOM(ids ...string) *FakeDOM {
+	d := &FakeDOM{
+		elements: make(map[string]*fakeElement),
+		storage:  make(map[string]string),
+	}
+	for _, id := range ids {
+		d.elements[id] = &fakeElement{tag: "div", properties: make(map[string]string)}
+	}
+	return d
+}
+
+func (d *FakeDOM) Property(id, name string) (string, bool) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	element, found := d.elements[id]
+	if !found {
+		return "", false
+	}
+	return element.properties[name], true
+}
+
+func (d *FakeDOM) SetProperty(id, name, value string) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	element, found := d.elements[id]
+	if !found {
+		return false
+	}
+	element.properties[name] = value
+	// replacing the markup drops the children, as in a browser
+	if name == "innerHTML" || name == "textContent" {
+		d.removeChildren(element)
+	}
+	return true
+}
+
+func (d *FakeDOM) AppendChild(id, tag string, properties map[string]string) bool {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	parent, found := d.elements[id]
+	if !found {
+		return false
+	}
+	child := &fakeElement{tag: tag, properties: make(map[string]string)}
+	for name, value := range properties {
+		child.properties[name] = value
+	}
+	parent.children = append(parent.children, child)
+	// children with an id can be looked up until their parent is cleared
+	if id := properties["id"]; id != "" {
+		d.elements[id] = child
+	}
+	return true
+}
+
+func (d *FakeDOM) removeChildren(element *fakeElement) {
+	for _, child := range element.children {
+		if id := child.properties["id"]; id != "" && d.elements[id] == child {
+			delete(d.elements, id)
+		}
+		d.removeChildren(child)
+	}
+	element.children = nil
+}
+
+func (d *FakeDOM) StorageItem(key string) (string, bool) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	value, found := d.storage[key]
+	return value, found
+}
+
+func (d *FakeDOM) SetStorageItem(key, value string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.storage[key] = value
+}
+
+func (d *FakeDOM) RemoveStorageItem(key string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.storage, key)
+}
+
+// Children returns the value of property name of every child of the
+// element with id
+func (d *FakeDOM) Children(id, name string) []string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	element, found := d.elements[id]
+	if !found {
+		return nil
+	}
+	values := make([]string, 0, len(element.children))
+	for _, child := range element.children {
+		values = append(values, child.properties[name])
+	}
+	return values
+}
+
+// useFakeDOM renders into a new FakeDOM with the element ids and a fresh
+// store for the rest of the test
+func useFakeDOM(ids ...string) *FakeDOM {
+	fake := NewFakeDOM(ids...)
+	dom = fake
+	state = NewStore()
+	return fake
+}
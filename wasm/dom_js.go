@@ -0,0 +1,90 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"syscall/js"
+)
+
+func init() {
+	dom = jsDOM{}
+}
+
+// jsDOM is the DOM of the page the wasm module runs in
+type jsDOM struct{}
+
+func (jsDOM) element(id string) (js.Value, bool) {
+	jsDoc := js.Global().Get("document")
+	if !jsDoc.Truthy() {
+		log.Error("Unable to get document object in: ", id)
+		return js.Value{}, false
+	}
+	element := jsDoc.Call("getElementById", id)
+	return element, element.Truthy()
+}
+
+func (d jsDOM) Property(id, name string) (string, bool) {
+	element, found := d.element(id)
+	if !found {
+		return "", false
+	}
+	value := element.Get(name)
+	if value.Type() == js.TypeString {
+		return value.String(), true
+	}
+	// booleans and numbers are returned the way javascript prints them
+	return js.Global().Call("String", value).String(), true
+}
+
+func (d jsDOM) SetProperty(id, name, value string) bool {
+	element, found := d.element(id)
+	if !found {
+		return false
+	}
+	element.Set(name, value)
+	return true
+}
+
+func (d jsDOM) AppendChild(id, tag string, properties map[string]string) bool {
+	parent, found := d.element(id)
+	if !found {
+		return false
+	}
+	child := js.Global().Get("document").Call("createElement", tag)
+	for name, value := range properties {
+		child.Set(name, value)
+	}
+	parent.Call("appendChild", child)
+	return true
+}
+
+func (jsDOM) StorageItem(key string) (string, bool) {
+	storage := js.Global().Get("localStorage")
+	if !storage.Truthy() {
+		return "", false
+	}
+	value := storage.Call("getItem", key)
+	if value.Type() != js.TypeString {
+		return "", false
+	}
+	return value.String(), true
+}
+
+func (jsDOM) SetStorageItem(key, value string) {
+	storage := js.Global().Get("localStorage")
+	if !storage.Truthy() {
+		log.Warn("Unable to get localStorage for: ", key)
+		return
+	}
+	storage.Call("setItem", key, value)
+}
+
+func (jsDOM) RemoveStorageItem(key string) {
+	storage := js.Global().Get("localStorage")
+	if !storage.Truthy() {
+		return
+	}
+	storage.Call("removeItem", key)
+}
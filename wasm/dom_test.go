@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestStatusEventRendersDashboard(t *testing.T) {
+	fake := useFakeDOM("taskmanagerstatusname", "taskmanagerstatusstatus", "taskmanagerstatusAS",
+		"LoggedIn", "DaemonRunning", "percentageNumber", "Rpc", "Http", "Proxy", "RestartBanner")
+	WatchDashboard()
+	err := StatusEvent([]byte(`{
+		"LoggedIn": true,
+		"DaemonRunning": false,
+		"TotalUptimePercentage": {"Percentage": 99.5},
+		"SessionStartTime": 1600000000,
+		"TaskManagerStatus": [
+			{"Name": "Idle", "Status": "Waiting"},
+			{"Name": "Sync", "Status": "Running", "AdditionalStatus": "3 files"},
+			{"Name": "GC", "Status": "Done"}
+		],
+		"ServerStatus": {"Rpc": "127.0.0.1:4343"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{
+		"LoggedIn":         "LoggedIn",
+		"DaemonRunning":    "OFFLINE",
+		"percentageNumber": "99.50 %",
+		"Rpc":              "127.0.0.1:4343",
+		"Http":             "Not Running",
+	} {
+		got, _ := fake.Property(id, "textContent")
+		if got != want {
+			t.Errorf("%s: got %q, want %q", id, got, want)
+		}
+	}
+	if got, want := fake.Children("taskmanagerstatusname", "innerHTML"), []string{"Sync", "GC"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("task names: got %q, want %q", got, want)
+	}
+	if got, want := fake.Children("taskmanagerstatusAS", "innerHTML"), []string{"3 files", "&#8212;"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("additional status: got %q, want %q", got, want)
+	}
+	if started, _ := fake.StorageItem("DaemonStartedAt"); started != "1600000000" {
+		t.Errorf("DaemonStartedAt: got %q", started)
+	}
+}
+
+func TestStatusEventMalformed(t *testing.T) {
+	useFakeDOM()
+	err := StatusEvent([]byte(`{"LoggedIn": "yes"}`))
+	if err == nil {
+		t.Fatal("expected an error for a malformed status")
+	}
+	if _, found := state.Status(); found {
+		t.Error("malformed status was stored")
+	}
+}
+
+func TestCheckBannerAfterPortChange(t *testing.T) {
+	fake := useFakeDOM("RestartBanner")
+	fake.SetStorageItem("DaemonStartedAt", "100")
+	fake.SetStorageItem("RefreshState", "Not Refreshed")
+
+	state.SetStatus(client.Status{SessionStartTime: 100})
+	CheckBanner()
+	if style, _ := fake.Property("RestartBanner", "style"); style != "display: block;" {
+		t.Errorf("banner hidden before the daemon restarted: %q", style)
+	}
+
+	state.SetStatus(client.Status{SessionStartTime: 200})
+	CheckBanner()
+	if style, _ := fake.Property("RestartBanner", "style"); style != "display: none;" {
+		t.Errorf("banner shown after the daemon restarted: %q", style)
+	}
+	if refresh, _ := fake.StorageItem("RefreshState"); refresh != "Refreshed" {
+		t.Errorf("RefreshState: got %q", refresh)
+	}
+}
+
+func TestUpdateSwarmPortRejectsInvalidPort(t *testing.T) {
+	for _, tc := range []struct {
+		port string
+		want string
+	}{
+		{"", "Enter A Valid Port Number"},
+		{"abc", "Port abc is Not a Number"},
+		{"80", "Port 80 is Unavailable"},
+	} {
+		fake := useFakeDOM("SwrmPortStatus", "SwrmPortNumber")
+		fake.SetProperty("SwrmPortNumber", "value", tc.port)
+		UpdateSwarmPort()
+		if got, _ := fake.Property("SwrmPortStatus", "innerHTML"); got != tc.want {
+			t.Errorf("port %q: got %q, want %q", tc.port, got, tc.want)
+		}
+		if style, _ := fake.Property("SwrmPortStatus", "style"); style != "color: red;" {
+			t.Errorf("port %q: got style %q", tc.port, style)
+		}
+	}
+}
+
+func TestNotificationHistoryPersists(t *testing.T) {
+	fake := useFakeDOM()
+	notifications = nil
+	Notify(LevelWarning, "Event stream", "reconnecting", "")
+	notifications = nil
+	LoadNotifications()
+	if len(notifications) != 1 || notifications[0].Title != "Event stream" {
+		t.Fatalf("history not restored: %+v", notifications)
+	}
+
+	fake.SetStorageItem(NotificationStorageKey, "{")
+	notifications = nil
+	LoadNotifications()
+	if _, found := fake.StorageItem(NotificationStorageKey); found {
+		t.Error("malformed history was kept")
+	}
+}
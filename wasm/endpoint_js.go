@@ -0,0 +1,259 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"syscall/js"
+)
+
+const (
+	EndpointParam      = "daemon"
+	BinaryParam        = "binary"
+	EndpointConfigFile = "hive-config.json"
+)
+
+var (
+	fileConfig     *EndpointConfig
+	fileConfigOnce sync.Once
+	fileConfigErr  error
+)
+
+// EndpointConfig is the format of hive-config.json and window.hiveConfig
+type EndpointConfig struct {
+	Daemon string `json:"daemon"`
+	// Binary is the hive-cli binary name on the daemon's host, detected from
+	// the daemon's platform when empty
+	Binary string `json:"binary"`
+}
+
+// Connect prepares the daemon client before any request is sent with it
+func Connect() {
+	defer close(clientReady)
+	LoadEndpoint()
+	CheckSession()
+	LoadBinary()
+}
+
+// LoadEndpoint resolves the daemon endpoint from the "daemon" query parameter,
+// window.hiveConfig or hive-config.json served next to hive.wasm, in that
+// order. When none of them is set the daemon is reached through the dashboard
+// server on the page's own origin, and DAEMON when the page is not served
+// over http.
+func LoadEndpoint() {
+	sources := []func() (string, error){
+		EndpointFromQuery,
+		EndpointFromWindow,
+		EndpointFromFile,
+		EndpointFromOrigin,
+	}
+	for _, source := range sources {
+		endpoint, err := source()
+		if err != nil {
+			log.Error("Error in reading daemon endpoint: ", err.Error())
+			continue
+		}
+		if endpoint == "" {
+			continue
+		}
+		err = hive.SetBaseURL(ResolveEndpoint(endpoint))
+		if err != nil {
+			log.Error("Invalid daemon endpoint: ", err.Error())
+			continue
+		}
+		break
+	}
+	log.Debugf("Using daemon endpoint: %s", hive.BaseURL())
+}
+
+// LoadBinary sets the hive-cli binary name from window.hiveConfig or
+// hive-config.json, and otherwise from the platform reported by the daemon.
+func LoadBinary() {
+	binary, err := BinaryFromWindow()
+	if err != nil {
+		log.Error("Error in reading hive-cli binary: ", err.Error())
+	}
+	if binary == "" {
+		config, err := ReadEndpointConfig()
+		if err != nil {
+			log.Error("Error in reading hive-cli binary: ", err.Error())
+		} else if config != nil {
+			binary = config.Binary
+		}
+	}
+	if binary != "" {
+		err = hive.SetBinary(binary)
+		if err != nil {
+			log.Error("Invalid hive-cli binary: ", err.Error())
+		}
+	} else {
+		err = hive.DetectBinary(context.Background())
+		if err != nil {
+			log.Error("Error in detecting hive-cli binary in LoadBinary: ", err.Error())
+		}
+	}
+	log.Debugf("Using hive-cli binary: %s", hive.Binary())
+}
+
+// ResolveEndpoint resolves an endpoint relative to the page, so "/" or
+// "/hive" address the dashboard server itself.
+func ResolveEndpoint(endpoint string) (resolved string) {
+	location := js.Global().Get("location")
+	if !location.Truthy() {
+		return endpoint
+	}
+	defer func() {
+		// URL throws on endpoints it can't parse, leave those to SetBaseURL
+		if recover() != nil {
+			resolved = endpoint
+		}
+	}()
+	return js.Global().Get("URL").New(endpoint, location.Get("href")).Get("href").String()
+}
+
+func EndpointFromQuery() (string, error) {
+	location := js.Global().Get("location")
+	if !location.Truthy() {
+		return "", nil
+	}
+	params := js.Global().Get("URLSearchParams").New(location.Get("search"))
+	value := params.Call("get", EndpointParam)
+	if value.Type() != js.TypeString {
+		return "", nil
+	}
+	return value.String(), nil
+}
+
+func EndpointFromWindow() (string, error) {
+	return windowConfig(EndpointParam)
+}
+
+func BinaryFromWindow() (string, error) {
+	return windowConfig(BinaryParam)
+}
+
+func windowConfig(key string) (string, error) {
+	hiveConfig := js.Global().Get("hiveConfig")
+	if !hiveConfig.Truthy() {
+		return "", nil
+	}
+	value := hiveConfig.Get(key)
+	if value.Type() == js.TypeUndefined {
+		return "", nil
+	}
+	if value.Type() != js.TypeString {
+		return "", fmt.Errorf("window.hiveConfig.%s is not a string", key)
+	}
+	return value.String(), nil
+}
+
+func EndpointFromFile() (string, error) {
+	config, err := ReadEndpointConfig()
+	if err != nil || config == nil {
+		return "", err
+	}
+	return config.Daemon, nil
+}
+
+// ReadEndpointConfig fetches hive-config.json from the directory of
+// wasm_exec.js once. It returns nil when the page isn't served over http or
+// the file doesn't exist.
+func ReadEndpointConfig() (*EndpointConfig, error) {
+	fileConfigOnce.Do(func() {
+		fileConfig, fileConfigErr = fetchEndpointConfig()
+	})
+	return fileConfig, fileConfigErr
+}
+
+func fetchEndpointConfig() (*EndpointConfig, error) {
+	location := js.Global().Get("location")
+	if !location.Truthy() {
+		return nil, nil
+	}
+	configURL := js.Global().Get("URL").New(EndpointConfigFile, assetBase(location)).Call("toString").String()
+	resp, err := http.Get(configURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", EndpointConfigFile, resp.Status)
+	}
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var config EndpointConfig
+	err = json.Unmarshal(buf, &config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", EndpointConfigFile, err.Error())
+	}
+	return &config, nil
+}
+
+// assetBase returns the URL the dashboard assets are served under, so
+// hive-config.json is read from next to hive.wasm whatever the page's path:
+// the src of the wasm_exec.js script that loads hive.wasm, and otherwise the
+// document's base URL, which follows a <base> element.
+func assetBase(location js.Value) js.Value {
+	document := js.Global().Get("document")
+	if !document.Truthy() {
+		return location.Get("href")
+	}
+	if document.Get("querySelector").Type() == js.TypeFunction {
+		script := document.Call("querySelector", `script[src$="wasm_exec.js"]`)
+		if script.Truthy() && script.Get("src").Type() == js.TypeString {
+			return script.Get("src")
+		}
+	}
+	if baseURI := document.Get("baseURI"); baseURI.Type() == js.TypeString {
+		return baseURI
+	}
+	return location.Get("href")
+}
+
+func EndpointFromOrigin() (string, error) {
+	location := js.Global().Get("location")
+	if !location.Truthy() {
+		return "", nil
+	}
+	protocol := location.Get("protocol").String()
+	if protocol != "http:" && protocol != "https:" {
+		return "", nil
+	}
+	return location.Get("origin").String(), nil
+}
+
+// SetEndpoint switches the daemon the dashboard talks to and restarts the
+// event stream if one is running.
+func SetEndpoint() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || args[0].Type() != js.TypeString {
+			return js.Global().Get("Error").New("SetEndpoint expects a daemon url")
+		}
+		err := hive.SetBaseURL(ResolveEndpoint(args[0].String()))
+		if err != nil {
+			log.Error("Error in setting daemon endpoint: ", err.Error())
+			return js.Global().Get("Error").New(err.Error())
+		}
+		log.Debugf("Switched daemon endpoint to: %s", hive.BaseURL())
+		UseSession()
+		eventsMtx.Lock()
+		running := stopEvents != nil
+		eventsMtx.Unlock()
+		if running {
+			StartEvents()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,419 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+const (
+	// EventStaleTimeout is how long the stream may stay quiet before it is shown as stale
+	EventStaleTimeout = 30 * time.Second
+	// EventDeadTimeout is how long the stream may stay quiet before it is reconnected
+	EventDeadTimeout = 90 * time.Second
+	// EventBackoffMin and EventBackoffMax bound the delay between reconnect attempts
+	EventBackoffMin = 1 * time.Second
+	EventBackoffMax = 60 * time.Second
+)
+
+type ConnectionState string
+
+const (
+	StateConnecting ConnectionState = "connecting"
+	StateLive       ConnectionState = "live"
+	StateStale      ConnectionState = "stale"
+	StateOffline    ConnectionState = "offline"
+)
+
+// EventTimers are the clock and timeouts of the event stream
+type EventTimers struct {
+	// Stale and Dead are how long the stream may stay quiet before it is
+	// shown as stale, and before it is reconnected
+	Stale time.Duration
+	Dead  time.Duration
+	// Tick is how often a quiet stream is checked
+	Tick  time.Duration
+	Now   func() time.Time
+	After func(time.Duration) <-chan time.Time
+}
+
+var (
+	eventsMtx  sync.Mutex
+	stopEvents context.CancelFunc
+
+	eventTimers = EventTimers{
+		Stale: EventStaleTimeout,
+		Dead:  EventDeadTimeout,
+		Tick:  time.Second,
+		Now:   time.Now,
+		After: time.After,
+	}
+)
+
+// StartEvents starts reading the daemon event stream, stopping the stream
+// already running if any.
+func StartEvents() {
+	eventsMtx.Lock()
+	defer eventsMtx.Unlock()
+	if stopEvents != nil {
+		stopEvents()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopEvents = cancel
+	go func() {
+		readEvents(ctx, Hive(), eventTimers)
+	}()
+}
+
+// StopEvents stops reading the daemon event stream
+func StopEvents() {
+	eventsMtx.Lock()
+	defer eventsMtx.Unlock()
+	if stopEvents != nil {
+		stopEvents()
+		stopEvents = nil
+	}
+	SetConnectionState(StateOffline)
+}
+
+// SetConnectionState shows the state of the event stream on the dashboard
+func SetConnectionState(state ConnectionState) {
+	log.Debugf("Event stream is %s", state)
+	Attributes := make(map[string]string)
+	Attributes["innerHTML"] = strings.ToUpper(string(state))
+	Attributes["className"] = fmt.Sprintf("ConnectionState_Class %s", state)
+	SetMultipleDisplay("ConnectionState", Attributes)
+}
+
+// Backoff returns the delay before reconnect attempt number attempt. The
+// delay doubles on every attempt up to EventBackoffMax and is jittered
+// between half and all of it so that dashboards don't reconnect in lockstep.
+func Backoff(attempt int, random *rand.Rand) time.Duration {
+	delay := EventBackoffMin
+	for i := 0; i < attempt && delay < EventBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > EventBackoffMax {
+		delay = EventBackoffMax
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + random.Int63n(half+1))
+}
+
+// readEvents reads the event stream of hive until ctx is done, reconnecting
+// with Backoff whenever it closes
+func readEvents(ctx context.Context, hive *client.Client, timers EventTimers) {
+	log.Debug("Events Called")
+	random := rand.New(rand.NewSource(timers.Now().UnixNano()))
+	attempt := 0
+	for {
+		SetConnectionState(StateConnecting)
+		received, err := streamEvents(ctx, hive, timers)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			attempt = 0
+		}
+		SetConnectionState(StateOffline)
+		delay := Backoff(attempt, random)
+		log.Warnf("Event stream closed: %s, reconnecting in %s", err.Error(), delay)
+		if received {
+			Notify(LevelWarning, "Event stream closed", "Lost the connection to the daemon, reconnecting", err.Error())
+		}
+		attempt++
+		select {
+		case <-ctx.Done():
+			return
+		case <-timers.After(delay):
+		}
+	}
+}
+
+// streamEvents reads the event stream until it fails, closes or goes quiet
+// for longer than timers.Dead. It reports whether any event was read.
+func streamEvents(ctx context.Context, hive *client.Client, timers EventTimers) (bool, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp, err := hive.Events(streamCtx)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	SetConnectionState(StateLive)
+	activity := make(chan struct{}, 1)
+	go watchEvents(streamCtx, cancel, activity, timers)
+	received := false
+	// malformed events are logged one by one but raise a single toast per
+	// connection, a daemon sending them keeps doing so
+	warned := false
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			select {
+			case activity <- struct{}{}:
+			default:
+			}
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			received = true
+			log.Debugf("This is the Events Data String: %s", line)
+			dispatchErr := DispatchEvent(line)
+			if dispatchErr != nil {
+				log.Error("Skipping event: ", dispatchErr.Error())
+				if !warned {
+					warned = true
+					Notify(LevelWarning, "Invalid daemon event", "An event from the daemon could not be shown, later ones are only logged", dispatchErr.Error())
+				}
+			}
+		}
+		if err != nil {
+			if streamCtx.Err() != nil && ctx.Err() == nil {
+				return received, fmt.Errorf("no events for %s", timers.Dead)
+			}
+			if err == io.EOF {
+				return received, errors.New("stream ended")
+			}
+			return received, err
+		}
+	}
+}
+
+// watchEvents marks the stream stale once it has been quiet for
+// timers.Stale and cancels it after timers.Dead.
+func watchEvents(ctx context.Context, cancel context.CancelFunc, activity <-chan struct{}, timers EventTimers) {
+	ticker := time.NewTicker(timers.Tick)
+	defer ticker.Stop()
+	lastSeen := timers.Now()
+	stale := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-activity:
+			lastSeen = timers.Now()
+			if stale {
+				stale = false
+				SetConnectionState(StateLive)
+			}
+		case <-ticker.C:
+			idle := timers.Now().Sub(lastSeen)
+			if idle > timers.Dead {
+				cancel()
+				return
+			}
+			if idle > timers.Stale && !stale {
+				stale = true
+				SetConnectionState(StateStale)
+			}
+		}
+	}
+}
+
+// DispatchEvent decodes a single line of the event stream and hands its data
+// to the handlers of its topic.
+func DispatchEvent(line []byte) error {
+	var event client.Event
+	err := json.Unmarshal(line, &event)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling eventsDataString: %s", err.Error())
+	}
+	RecordHistory(&event)
+	var out client.Out
+	log.Debugf("This is event: %s", event.Result.Topic)
+	err = json.Unmarshal([]byte(event.Result.Val), &out)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling Out in %s: %s", event.Result.Topic, err.Error())
+	}
+	val, err := json.Marshal(out.Data)
+	if err != nil {
+		return fmt.Errorf("Error encountered in Marshalling: %s", err.Error())
+	}
+	return HandleEvent(event.Result.Topic, val)
+}
+
+// StatusEvent stores the daemon status
+func StatusEvent(val json.RawMessage) error {
+	log.Debug("Status Hit")
+	var status client.Status
+	err := json.Unmarshal(val, &status)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling Status: %s", err.Error())
+	}
+	log.Debug("This is Status: ", status)
+	state.SetStatus(status)
+	RecordHistory(&status.TotalUptimePercentage)
+	return nil
+}
+
+// BalanceEvent stores the confirmed balance
+func BalanceEvent(val json.RawMessage) error {
+	log.Debug("Balance Hit")
+	balance, err := strconv.ParseFloat(strings.TrimSpace(string(val)), 64)
+	if err != nil {
+		return fmt.Errorf("Error in parsing Balance: %s", err.Error())
+	}
+	state.SetBalance(balance)
+	RecordHistory(&client.Balance{Balance: balance})
+	return nil
+}
+
+// SettlementEvent stores the next distribution
+func SettlementEvent(val json.RawMessage) error {
+	log.Debug("Settlement Hit")
+	var settlement client.Settlement
+	err := json.Unmarshal(val, &settlement)
+	if err != nil {
+		return fmt.Errorf("Error Unmarshalling settlement: %s", err.Error())
+	}
+	log.Debug("This is Settlement: ", settlement)
+	state.SetSettlement(settlement)
+	return nil
+}
+
+// BalanceCycleEvent stores the balance and traffic of the current billing cycle
+func BalanceCycleEvent(val json.RawMessage) error {
+	log.Debug("BCN Hit")
+	var bcnBalance client.BCNBalance
+	err := json.Unmarshal(val, &bcnBalance)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling BCN Balance: %s", err.Error())
+	}
+	log.Debug("This is Balance Cycle: ", bcnBalance)
+	state.SetBCNBalance(bcnBalance)
+	return nil
+}
+
+// PeersEvent stores the number of peers and refreshes the peer list
+func PeersEvent(val json.RawMessage) error {
+	log.Debug("Peers Hit")
+	log.Debugf("This is Number of Peers: %s", val)
+	var count int
+	err := json.Unmarshal(val, &count)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling Peers: %s", err.Error())
+	}
+	state.SetPeerCount(count)
+	go GetPeers()
+	return nil
+}
+
+// SettingsEvent stores the storage settings
+func SettingsEvent(val json.RawMessage) error {
+	log.Debug("Settings Hit")
+	var settings client.Settings
+	err := json.Unmarshal(val, &settings)
+	if err != nil {
+		return fmt.Errorf("Error in Unmarshalling Settings: %s", err.Error())
+	}
+	log.Debug("This is Settings: ", settings)
+	state.SetSettings(settings)
+	return nil
+}
+
+// WatchDashboard renders the dashboard widgets fed by the event stream
+// whenever their state changes
+func WatchDashboard() {
+	state.Watch("Status", RenderStatus, KeyStatus)
+	state.Watch("Balance", RenderBalance, KeyBalance)
+	state.Watch("Settlement", RenderSettlement, KeySettlement)
+	state.Watch("BalanceCycle", RenderBalanceCycle, KeyBCNBalance)
+	state.Watch("PeerCount", RenderPeerCount, KeyPeerCount)
+	state.Watch("Peers", RenderPeers, KeyPeers)
+	state.Watch("Storage", RenderStorage, KeySettings)
+	state.Watch("Bandwidth", RenderBandwidth, KeyBandwidth)
+}
+
+// RenderStatus renders the daemon status, task manager and server details
+func RenderStatus() {
+	status, _ := state.Status()
+	SetDisplay("taskmanagerstatusname", "innerHTML", "")
+	SetDisplay("taskmanagerstatusstatus", "innerHTML", "")
+	SetDisplay("taskmanagerstatusAS", "innerHTML", "")
+	for _, task := range status.TaskManagerStatus {
+		sName := task.Name
+		if sName == "Idle" {
+			continue
+		}
+		CreateElement("taskmanagerstatusname", "div", "innerHTML", sName)
+		sStatus := task.Status
+		CreateElement("taskmanagerstatusstatus", "div", "innerHTML", sStatus)
+		sAdditionalStatus := task.AdditionalStatus
+		if sAdditionalStatus == "" {
+			sAdditionalStatus = fmt.Sprintf("&#8212;")
+		}
+		CreateElement("taskmanagerstatusAS", "div", "innerHTML", sAdditionalStatus)
+	}
+	err := Bind(status)
+	if err != nil {
+		log.Error("Error in binding Status: ", err.Error())
+	}
+	log.Debug("Daemon Started at: ", status.SessionStartTime)
+	CheckBanner()
+}
+
+// RenderBalance renders the confirmed balance
+func RenderBalance() {
+	balance, _ := state.Balance()
+	err := Bind(struct {
+		Balance float64 `dom:"confirmedBalance,format=swrm"`
+	}{balance})
+	if err != nil {
+		log.Error("Error in binding Balance: ", err.Error())
+	}
+}
+
+// RenderSettlement renders the date of the next distribution
+func RenderSettlement() {
+	settlement, _ := state.Settlement()
+	SetDisplay("NextDistribution", "innerHTML", FormatSettlement(settlement.Date, time.Local))
+}
+
+// FormatSettlement renders a settlement date as day-month-year and kitchen
+// time in loc
+func FormatSettlement(date time.Time, loc *time.Location) string {
+	CurrentZone := date.In(loc)
+	sDate := CurrentZone.Format("02-01-2006")
+	kitchen := CurrentZone.Format(time.Kitchen)
+	return fmt.Sprintf("%s %s", sDate, kitchen)
+}
+
+// RenderBalanceCycle renders the pending balance and traffic of the current billing cycle
+func RenderBalanceCycle() {
+	bcnBalance, _ := state.BCNBalance()
+	sValue := fmt.Sprintf("%f %s", (bcnBalance.Owned - bcnBalance.Owe), "SWRM")
+	SetDisplay("Pending", "innerHTML", sValue)
+	err := Bind(bcnBalance)
+	if err != nil {
+		log.Error("Error in binding Balance Cycle: ", err.Error())
+	}
+}
+
+// RenderPeerCount renders the number of peers
+func RenderPeerCount() {
+	count, _ := state.PeerCount()
+	SetDisplay("PeersData", "innerHTML", fmt.Sprintf("%d", count))
+}
+
+// RenderStorage renders the storage settings
+func RenderStorage() {
+	settings, _ := state.Settings()
+	err := Bind(settings)
+	if err != nil {
+		log.Error("Error in binding Settings: ", err.Error())
+	}
+}
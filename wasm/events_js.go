@@ -0,0 +1,17 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"syscall/js"
+)
+
+func Events() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		WatchDashboard()
+		StartEvents()
+		return nil
+	})
+}
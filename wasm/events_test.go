@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	buf, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+var registerHandlers sync.Once
+
+func TestEventHandlers(t *testing.T) {
+	for _, tc := range []struct {
+		fixture string
+		handler func(json.RawMessage) error
+		want    map[string]string
+	}{
+		{
+			fixture: "status.json",
+			handler: StatusEvent,
+			want: map[string]string{
+				"LoggedIn":         "LoggedOut",
+				"DaemonRunning":    "ONLINE",
+				"percentageNumber": "100.00 %",
+				"Rpc":              "Running",
+				"Http":             "Not Running",
+			},
+		},
+		{
+			fixture: "balance.json",
+			handler: BalanceEvent,
+			want:    map[string]string{"confirmedBalance": "12.3456 SWRM"},
+		},
+		{
+			fixture: "balance_cycle.json",
+			handler: BalanceCycleEvent,
+			want: map[string]string{
+				"CycleServed":     "5.0 TB",
+				"CycleDownloaded": "1.5 KB",
+			},
+		},
+		{
+			fixture: "settings.json",
+			handler: SettingsEvent,
+			want: map[string]string{
+				"MaxStorage":  "4096.00 GB",
+				"UsedStorage": "1234.57 GB",
+			},
+		},
+	} {
+		t.Run(tc.fixture, func(t *testing.T) {
+			ids := make([]string, 0, len(tc.want))
+			for id := range tc.want {
+				ids = append(ids, id)
+			}
+			fake := useFakeDOM(ids...)
+			WatchDashboard()
+			err := tc.handler(readFixture(t, tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, want := range tc.want {
+				if got, _ := fake.Property(id, "textContent"); got != want {
+					t.Errorf("%s: got %q, want %q", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInnerHTMLHandlers(t *testing.T) {
+	fake := useFakeDOM("Pending", "PeersData")
+	WatchDashboard()
+	err := BalanceCycleEvent(readFixture(t, "balance_cycle.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PeersEvent(readFixture(t, "peers.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fake.Property("Pending", "innerHTML"); got != "1.500000 SWRM" {
+		t.Errorf("Pending: got %q", got)
+	}
+	if got, _ := fake.Property("PeersData", "innerHTML"); got != "7" {
+		t.Errorf("PeersData: got %q", got)
+	}
+}
+
+func TestSettlementEvent(t *testing.T) {
+	useFakeDOM()
+	err := SettlementEvent(readFixture(t, "settlement.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settlement, found := state.Settlement()
+	if !found {
+		t.Fatal("settlement wasn't stored")
+	}
+	if want := time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC); !settlement.Date.Equal(want) {
+		t.Errorf("got %s, want %s", settlement.Date, want)
+	}
+	if settlement.Cycle != 18629 || settlement.Rate != 1.25e-10 {
+		t.Errorf("got %+v", settlement)
+	}
+}
+
+func TestFormatSettlement(t *testing.T) {
+	date := time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		loc  *time.Location
+		want string
+	}{
+		{time.UTC, "02-01-2021 3:04PM"},
+		{time.FixedZone("IST", 5*3600+1800), "02-01-2021 8:34PM"},
+		{time.FixedZone("PST", -8*3600), "02-01-2021 7:04AM"},
+		{time.FixedZone("NZDT", 13*3600), "03-01-2021 4:04AM"},
+	} {
+		if got := FormatSettlement(date, tc.loc); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.loc, got, tc.want)
+		}
+	}
+}
+
+func TestMalformedEvents(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler func(json.RawMessage) error
+		val     string
+	}{
+		{"status truncated", StatusEvent, `{"LoggedIn": true`},
+		{"status wrong type", StatusEvent, `{"SessionStartTime": "yesterday"}`},
+		{"balance text", BalanceEvent, `"a lot"`},
+		{"balance empty", BalanceEvent, ``},
+		{"settlement date", SettlementEvent, `{"settlementDate": "tomorrow"}`},
+		{"balance cycle", BalanceCycleEvent, `{"owned": "1"}`},
+		{"peers", PeersEvent, `[1, 2]`},
+		{"settings", SettingsEvent, `[]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			useFakeDOM()
+			err := tc.handler(json.RawMessage(tc.val))
+			if err == nil {
+				t.Fatalf("accepted %s", tc.val)
+			}
+			for _, key := range []StateKey{KeyStatus, KeyBalance, KeySettlement, KeyBCNBalance, KeyPeerCount, KeySettings} {
+				if state.Has(key) {
+					t.Errorf("stored %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchEvent(t *testing.T) {
+	registerHandlers.Do(RegisterDefaultTopicHandlers)
+	fake := useFakeDOM("confirmedBalance")
+	WatchDashboard()
+	err := DispatchEvent(readFixture(t, "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fake.Property("confirmedBalance", "textContent"); got != "-0.5 SWRM" {
+		t.Errorf("got %q", got)
+	}
+	for _, line := range []string{
+		`{"Result": `,
+		`{"Result": {"topic": "Balance", "val": "{\"status\": 200"}}`,
+		`{"Result": {"topic": "Balance", "val": "{\"status\":200,\"data\":\"none\"}"}}`,
+	} {
+		if err := DispatchEvent([]byte(line)); err == nil {
+			t.Errorf("accepted %s", line)
+		}
+	}
+	if err := DispatchEvent([]byte(`{"Result": {"topic": "Unknown", "val": "{}"}}`)); err != nil {
+		t.Errorf("unknown topic: %s", err.Error())
+	}
+}
+
+func resetNotifications(t *testing.T) {
+	notifications, unreadCount = nil, 0
+	t.Cleanup(func() { notifications, unreadCount = nil, 0 })
+}
+
+// waitFor polls cond until it holds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// fastTimers are event timers short enough to test with
+func fastTimers() EventTimers {
+	return EventTimers{
+		Stale: 50 * time.Millisecond,
+		Dead:  300 * time.Millisecond,
+		Tick:  5 * time.Millisecond,
+		Now:   time.Now,
+		After: time.After,
+	}
+}
+
+const unknownEvent = `{"Result": {"topic": "Unknown", "val": "{}"}}` + "\n"
+
+func TestBackoff(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for attempt := 0; attempt < 10; attempt++ {
+		full := EventBackoffMin << uint(attempt)
+		if full > EventBackoffMax {
+			full = EventBackoffMax
+		}
+		min, max := full, time.Duration(0)
+		for i := 0; i < 200; i++ {
+			delay := Backoff(attempt, random)
+			if delay < full/2 || delay > full {
+				t.Fatalf("attempt %d: got %s, want between %s and %s", attempt, delay, full/2, full)
+			}
+			if delay < min {
+				min = delay
+			}
+			if delay > max {
+				max = delay
+			}
+		}
+		// the delays are spread over the jitter range
+		if max-min < full/4 {
+			t.Errorf("attempt %d: delays between %s and %s", attempt, min, max)
+		}
+	}
+}
+
+func TestReadEventsReconnects(t *testing.T) {
+	useFakeDOM("ConnectionState")
+	resetNotifications(t)
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the fourth connection delivers an event, the others fail
+		if atomic.AddInt32(&requests, 1) != 4 {
+			http.Error(w, "daemon starting", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(unknownEvent))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var delays []time.Duration
+	timers := fastTimers()
+	timers.After = func(delay time.Duration) <-chan time.Time {
+		delays = append(delays, delay)
+		if len(delays) == 6 {
+			cancel()
+		}
+		now := make(chan time.Time, 1)
+		now <- time.Now()
+		return now
+	}
+	readEvents(ctx, client.New(srv.URL), timers)
+
+	// the delay grows with every failed attempt and starts over once an
+	// event was read
+	for i, attempt := range []int{0, 1, 2, 0, 1, 2} {
+		full := EventBackoffMin << uint(attempt)
+		if delays[i] < full/2 || delays[i] > full {
+			t.Errorf("delay %d: got %s, want between %s and %s", i, delays[i], full/2, full)
+		}
+	}
+	if len(notifications) != 1 || notifications[0].Title != "Event stream closed" {
+		t.Errorf("got %d notifications, want one for the closed stream", len(notifications))
+	}
+}
+
+func TestStreamEventsGoesQuiet(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		close bool
+		err   string
+	}{
+		{"dead", false, "no events for 300ms"},
+		{"closed", true, "stream ended"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeDOM("ConnectionState")
+			resume := make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(unknownEvent))
+				w.(http.Flusher).Flush()
+				<-resume
+				w.Write([]byte(unknownEvent))
+				w.(http.Flusher).Flush()
+				if tc.close {
+					return
+				}
+				<-r.Context().Done()
+			}))
+			t.Cleanup(srv.Close)
+			connectionState := func(want ConnectionState) func() bool {
+				return func() bool {
+					got, _ := fake.Property("ConnectionState", "className")
+					return got == "ConnectionState_Class "+string(want)
+				}
+			}
+
+			var received bool
+			var err error
+			done := make(chan struct{})
+			go func() {
+				received, err = streamEvents(context.Background(), client.New(srv.URL), fastTimers())
+				close(done)
+			}()
+			waitFor(t, "a stale stream", connectionState(StateStale))
+			// an event brings it back to live
+			close(resume)
+			waitFor(t, "a live stream", connectionState(StateLive))
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the quiet stream wasn't closed")
+			}
+			if !received || err == nil || err.Error() != tc.err {
+				t.Errorf("got %v, %v, want true, %s", received, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestStreamEventsMalformed(t *testing.T) {
+	useFakeDOM("ConnectionState")
+	resetNotifications(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("{\"Result\": \n"))
+		}
+		w.Write([]byte(unknownEvent))
+	}))
+	t.Cleanup(srv.Close)
+	for connection := 1; connection <= 2; connection++ {
+		if _, err := streamEvents(context.Background(), client.New(srv.URL), fastTimers()); err == nil || err.Error() != "stream ended" {
+			t.Fatalf("got %v", err)
+		}
+		// one toast per connection, however many events were malformed,
+		// the repeat while it is shown bumps its count
+		if len(notifications) != 1 || notifications[0].Title != "Invalid daemon event" || notifications[0].Count != connection {
+			t.Errorf("connection %d: got %d notifications", connection, len(notifications))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// EarningsExport selects the earnings history to export. Device is a peer
+// ID or client.AllDevices, From and To bound the billing cycles and are
+// open when empty.
+type EarningsExport struct {
+	Format string `json:"format"`
+	Device string `json:"device"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// ExportFromPage returns the export of format for the device and cycle range
+// selected on the dashboard
+func ExportFromPage(format string) EarningsExport {
+	export := EarningsExport{Format: format, Device: client.AllDevices}
+	if device, found := dom.Property("DevicesDropDown", "value"); found && device != "" {
+		export.Device = device
+	}
+	export.From, _ = dom.Property("ExportFrom", "value")
+	export.To, _ = dom.Property("ExportTo", "value")
+	return export
+}
+
+// FileName is the name the export is downloaded as
+func (e EarningsExport) FileName() string {
+	device := "all-devices"
+	if e.Device != client.AllDevices {
+		device = e.Device
+	}
+	name := []string{"hive-earnings", device}
+	if e.From != "" {
+		name = append(name, e.From)
+	}
+	if e.To != "" && e.To != e.From {
+		name = append(name, e.To)
+	}
+	return fmt.Sprintf("%s.%s", strings.Join(name, "_"), e.Format)
+}
+
+// Encode fetches the earnings history and encodes the selected rows for
+// download, returning the data and its mime type
+func (e EarningsExport) Encode() ([]byte, string, error) {
+	netEarnings, err := Hive().Earnings(context.Background())
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := netEarnings.Rows(e.Device, e.From, e.To)
+	if err != nil {
+		return nil, "", err
+	}
+	return EncodeEarnings(rows, e.Format)
+}
+
+// EncodeEarnings encodes earnings rows as CSV or JSON. Amounts keep their
+// full precision, served and downloaded are in bytes.
+func EncodeEarnings(rows []client.EarningsRow, format string) ([]byte, string, error) {
+	switch format {
+	case ExportJSON:
+		if rows == nil {
+			rows = []client.EarningsRow{}
+		}
+		buf, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return nil, "", err
+		}
+		return buf, "application/json", nil
+	case ExportCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write([]string{"cycle", "device", "peer_id", "earned_swrm", "served_bytes", "downloaded_bytes"})
+		for _, row := range rows {
+			w.Write([]string{
+				row.Cycle,
+				csvText(row.Device),
+				csvText(row.PeerId),
+				strconv.FormatFloat(row.Earned, 'f', -1, 64),
+				strconv.FormatFloat(row.Served, 'f', -1, 64),
+				strconv.FormatFloat(row.Downloaded, 'f', -1, 64),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "text/csv", nil
+	}
+	return nil, "", fmt.Errorf("unknown export format %q", format)
+}
+
+// csvText keeps a text cell from being read as a formula by spreadsheets,
+// device names are chosen by users. Spreadsheets also start formulas after a
+// leading tab or carriage return.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+	"time"
+)
+
+// ExportEarnings downloads the earnings history as "csv" or "json". The
+// device and cycle range are taken from the dashboard unless given in an
+// options object, e.g. ExportEarnings("csv", {device: "ALL DEVICES", from:
+// "01-01-2021"}). It returns a promise resolved with the file name.
+func ExportEarnings() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		format := ExportCSV
+		if len(args) > 0 && args[0].Type() == js.TypeString {
+			format = args[0].String()
+		}
+		export := ExportFromPage(format)
+		if len(args) > 1 && args[1].Type() == js.TypeObject {
+			for key, value := range map[string]*string{"device": &export.Device, "from": &export.From, "to": &export.To} {
+				if option := args[1].Get(key); option.Type() == js.TypeString {
+					*value = option.String()
+				}
+			}
+		}
+		handler := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			resolve := args[0]
+			reject := args[1]
+			go func() {
+				data, mime, err := export.Encode()
+				if err != nil {
+					log.Error("Error in exporting earnings in ExportEarnings: ", err.Error())
+					Notify(LevelError, "Export failed", err.Error(), "")
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				name := export.FileName()
+				download(name, mime, data)
+				Notify(LevelSuccess, "Earnings exported", fmt.Sprintf("Saved %s", name), "")
+				resolve.Invoke(name)
+			}()
+			return nil
+		})
+		return js.Global().Get("Promise").New(handler)
+	})
+}
+
+// download saves data as a file through a temporary object URL
+func download(name, mime string, data []byte) {
+	buf := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(buf, data)
+	blob := js.Global().Get("Blob").New([]interface{}{buf}, map[string]interface{}{"type": mime})
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	// the download may start after click returns
+	time.AfterFunc(time.Minute, func() {
+		js.Global().Get("URL").Call("revokeObjectURL", url)
+	})
+	jsDoc := js.Global().Get("document")
+	link := jsDoc.Call("createElement", "a")
+	link.Set("href", url)
+	link.Set("download", name)
+	link.Get("style").Set("display", "none")
+	jsDoc.Get("body").Call("appendChild", link)
+	link.Call("click")
+	link.Call("remove")
+}
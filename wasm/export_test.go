@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestEncodeEarnings(t *testing.T) {
+	rows := []client.EarningsRow{
+		{Cycle: "01-01-2021", Device: "hive, node", PeerId: "QmNode1", Earned: 0.000000125, Served: 5497558138880, Downloaded: 512},
+		{Cycle: "02-01-2021", Device: "laptop", PeerId: "QmNode2", Earned: -0.1},
+	}
+	for _, tc := range []struct {
+		format string
+		rows   []client.EarningsRow
+		want   string
+		mime   string
+	}{
+		{
+			format: ExportCSV,
+			rows:   rows,
+			want: "cycle,device,peer_id,earned_swrm,served_bytes,downloaded_bytes\n" +
+				"01-01-2021,\"hive, node\",QmNode1,0.000000125,5497558138880,512\n" +
+				"02-01-2021,laptop,QmNode2,-0.1,0,0\n",
+			mime: "text/csv",
+		},
+		{
+			format: ExportCSV,
+			want:   "cycle,device,peer_id,earned_swrm,served_bytes,downloaded_bytes\n",
+			mime:   "text/csv",
+		},
+		{
+			format: ExportJSON,
+			rows:   rows[1:],
+			want: `[
+  {
+    "cycle": "02-01-2021",
+    "device": "laptop",
+    "peerId": "QmNode2",
+    "earned": -0.1,
+    "served": 0,
+    "downloaded": 0
+  }
+]`,
+			mime: "application/json",
+		},
+		{format: ExportJSON, want: "[]", mime: "application/json"},
+		// cells starting like a formula are kept as text, amounts aren't touched
+		{
+			format: ExportCSV,
+			rows: []client.EarningsRow{
+				{Cycle: "01-01-2021", Device: "=HYPERLINK(\"http://example.com\")", PeerId: "@QmNode1", Earned: -0.1},
+				{Cycle: "01-01-2021", Device: "+node", PeerId: "-QmNode2"},
+				{Cycle: "01-01-2021", Device: "\t=1+1", PeerId: "\r=1+1"},
+			},
+			want: "cycle,device,peer_id,earned_swrm,served_bytes,downloaded_bytes\n" +
+				"01-01-2021,\"'=HYPERLINK(\"\"http://example.com\"\")\",'@QmNode1,-0.1,0,0\n" +
+				"01-01-2021,'+node,'-QmNode2,0,0,0\n" +
+				"01-01-2021,'\t=1+1,\"'\r=1+1\",0,0,0\n",
+			mime: "text/csv",
+		},
+	} {
+		data, mime, err := EncodeEarnings(tc.rows, tc.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.want || mime != tc.mime {
+			t.Errorf("%s: got %s %q, want %s %q", tc.format, mime, data, tc.mime, tc.want)
+		}
+	}
+	if _, _, err := EncodeEarnings(rows, "xlsx"); err == nil {
+		t.Error("encoded an unknown format")
+	}
+}
+
+func TestExportFromPage(t *testing.T) {
+	fake := useFakeDOM("DevicesDropDown", "ExportFrom", "ExportTo")
+	RenderExportCycles([]string{"03-01-2021", "02-01-2021", "01-01-2021"})
+	if got := fake.Children("ExportFrom", "value"); len(got) != 3 || got[0] != "01-01-2021" {
+		t.Errorf("cycles: got %q", got)
+	}
+	export := ExportFromPage(ExportCSV)
+	if export.Device != client.AllDevices || export.FileName() != "hive-earnings_all-devices.csv" {
+		t.Errorf("got %+v %s", export, export.FileName())
+	}
+
+	fake.SetProperty("DevicesDropDown", "value", "QmNode1")
+	fake.SetProperty("ExportFrom", "value", "01-01-2021")
+	fake.SetProperty("ExportTo", "value", "02-01-2021")
+	export = ExportFromPage(ExportJSON)
+	want := EarningsExport{Format: ExportJSON, Device: "QmNode1", From: "01-01-2021", To: "02-01-2021"}
+	if export != want {
+		t.Errorf("got %+v, want %+v", export, want)
+	}
+	if name := export.FileName(); name != "hive-earnings_QmNode1_01-01-2021_02-01-2021.json" {
+		t.Errorf("got %s", name)
+	}
+}
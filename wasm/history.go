@@ -0,0 +1,400 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+	store "github.com/StreamSpace/ss-store"
+)
+
+const (
+	// HistoryCompactInterval is how often old snapshots are dropped and
+	// downsampled
+	HistoryCompactInterval = time.Hour
+	// HistoryQueueSize is how many snapshots may wait to be written before
+	// new ones are dropped
+	HistoryQueueSize = 256
+)
+
+// Snapshot is a copy of an item taken at a time. Snapshots are kept under
+// the namespace of their item, by time.
+type Snapshot struct {
+	Namespace string          `json:"-"`
+	Time      int64           `json:"time"`
+	Item      json.RawMessage `json:"item"`
+}
+
+// NewSnapshot copies item as it is at t
+func NewSnapshot(item store.SerializedItem, t time.Time) (*Snapshot, error) {
+	val, err := item.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		Namespace: item.GetNamespace(),
+		Time:      t.UnixNano() / int64(time.Millisecond),
+		Item:      val,
+	}, nil
+}
+
+func (s *Snapshot) GetNamespace() string {
+	return s.Namespace
+}
+
+// GetId is the time in milliseconds, padded so ids sort by time
+func (s *Snapshot) GetId() string {
+	return fmt.Sprintf("%020d", s.Time)
+}
+func (s *Snapshot) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+func (s *Snapshot) Unmarshal(val []byte) error {
+	return json.Unmarshal(val, s)
+}
+
+// At returns the time the snapshot was taken
+func (s *Snapshot) At() time.Time {
+	return time.Unix(0, s.Time*int64(time.Millisecond))
+}
+
+// Decode unmarshals the snapshot into item
+func (s *Snapshot) Decode(item store.Serializable) error {
+	return item.Unmarshal(s.Item)
+}
+
+// snapshots is the factory of the snapshots of a namespace
+type snapshots string
+
+func (n snapshots) Factory() store.SerializedItem {
+	return &Snapshot{Namespace: string(n)}
+}
+
+// Retention is how long the snapshots of a namespace are kept
+type Retention struct {
+	// MaxAge drops snapshots older than it
+	MaxAge time.Duration
+	// Recent keeps every snapshot younger than it, older ones are
+	// downsampled to one per Resolution. A zero Resolution keeps them all.
+	Recent     time.Duration
+	Resolution time.Duration
+	// MaxCount drops the oldest snapshots beyond it, zero for no limit
+	MaxCount int
+	// Merge turns the snapshots of a Resolution into one, by default the
+	// latest is kept
+	Merge func(snapshots []*Snapshot) (*Snapshot, error)
+	// Distinct keeps every snapshot recorded at the same time, a millisecond
+	// apart, instead of replacing the previous one
+	Distinct bool
+}
+
+// Retentions are the namespaces kept in the history
+var Retentions = map[string]Retention{
+	// bandwidth samples of the last day are kept as polled, older ones are
+	// averaged over 5 minutes for a week
+	"Bandwidth": {
+		MaxAge:     7 * 24 * time.Hour,
+		Recent:     BandwidthHistoryLength,
+		Resolution: 5 * time.Minute,
+		Merge:      mergeBandwidth,
+	},
+	"UserBalance": {
+		MaxAge:     90 * 24 * time.Hour,
+		Recent:     7 * 24 * time.Hour,
+		Resolution: time.Hour,
+	},
+	"UptimePercentage": {
+		MaxAge:     90 * 24 * time.Hour,
+		Recent:     24 * time.Hour,
+		Resolution: time.Hour,
+	},
+	"Event": {
+		MaxAge:   7 * 24 * time.Hour,
+		MaxCount: 5000,
+		Distinct: true,
+	},
+	"PeerEvent": {
+		MaxAge:   7 * 24 * time.Hour,
+		MaxCount: 5000,
+		Distinct: true,
+	},
+}
+
+// History persists snapshots of the dashboard state so they survive a
+// reload. Snapshots are queued by Record and written by Run.
+type History struct {
+	items store.Store
+	queue chan *Snapshot
+	now   func() time.Time
+
+	mtx    sync.Mutex
+	latest map[string]int64
+}
+
+// dashboardHistory is nil until the history is opened, on js
+var dashboardHistory *History
+
+func NewHistory(items store.Store) *History {
+	return &History{
+		items:  items,
+		queue:  make(chan *Snapshot, HistoryQueueSize),
+		now:    time.Now,
+		latest: make(map[string]int64),
+	}
+}
+
+// RecordHistory queues a snapshot of item if the history is open
+func RecordHistory(item store.SerializedItem) {
+	if dashboardHistory != nil {
+		dashboardHistory.Record(item)
+	}
+}
+
+// Record queues a snapshot of item taken now, it never blocks
+func (h *History) Record(item store.SerializedItem) {
+	retention, found := Retentions[item.GetNamespace()]
+	if !found {
+		log.Warnf("No retention for %s, not recording it", item.GetNamespace())
+		return
+	}
+	snapshot, err := NewSnapshot(item, h.now())
+	if err != nil {
+		log.Error("Error in snapshotting in Record: ", err.Error())
+		return
+	}
+	if retention.Distinct {
+		h.mtx.Lock()
+		if latest := h.latest[snapshot.Namespace]; snapshot.Time <= latest {
+			snapshot.Time = latest + 1
+		}
+		h.latest[snapshot.Namespace] = snapshot.Time
+		h.mtx.Unlock()
+	}
+	select {
+	case h.queue <- snapshot:
+	default:
+		log.Warnf("History queue is full, dropping a %s snapshot", snapshot.Namespace)
+	}
+}
+
+// Save writes a snapshot, replacing one taken at the same time
+func (h *History) Save(snapshot *Snapshot) error {
+	err := h.items.Create(snapshot)
+	if err == ErrRecordExists {
+		return h.items.Update(snapshot)
+	}
+	return err
+}
+
+// Flush writes the queued snapshots
+func (h *History) Flush() {
+	for {
+		select {
+		case snapshot := <-h.queue:
+			err := h.Save(snapshot)
+			if err != nil {
+				log.Error("Error in saving a snapshot in Flush: ", err.Error())
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Run compacts the history, then writes queued snapshots as they come and
+// compacts again every HistoryCompactInterval until ctx is done
+func (h *History) Run(ctx context.Context) {
+	err := h.Compact()
+	if err != nil {
+		log.Error("Error in compacting the history in Run: ", err.Error())
+	}
+	ticker := time.NewTicker(HistoryCompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.Flush()
+			return
+		case snapshot := <-h.queue:
+			err := h.Save(snapshot)
+			if err != nil {
+				log.Error("Error in saving a snapshot in Run: ", err.Error())
+			}
+		case <-ticker.C:
+			err := h.Compact()
+			if err != nil {
+				log.Error("Error in compacting the history in Run: ", err.Error())
+			}
+		}
+	}
+}
+
+// Snapshots returns the snapshots of a namespace taken at or after since,
+// oldest first
+func (h *History) Snapshots(namespace string, since time.Time) ([]*Snapshot, error) {
+	items, err := h.items.List(snapshots(namespace), store.ListOpt{
+		Filter: takenSince(since.UnixNano() / int64(time.Millisecond)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Snapshot, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.(*Snapshot))
+	}
+	return result, nil
+}
+
+type takenSince int64
+
+func (t takenSince) Compare(item store.SerializedItem) bool {
+	snapshot, ok := item.(*Snapshot)
+	return ok && snapshot.Time >= int64(t)
+}
+
+// Compact applies the retention of every namespace
+func (h *History) Compact() error {
+	now := h.now()
+	for namespace, retention := range Retentions {
+		removed, err := h.CompactNamespace(namespace, retention, now)
+		if err != nil {
+			return fmt.Errorf("%s: %s", namespace, err.Error())
+		}
+		if removed > 0 {
+			log.Debugf("Compacted %d %s snapshots", removed, namespace)
+		}
+	}
+	return nil
+}
+
+// CompactNamespace drops the snapshots of a namespace that are too old or
+// too many and downsamples the ones older than the recent ones. It returns
+// how many snapshots were removed.
+func (h *History) CompactNamespace(namespace string, retention Retention, now time.Time) (int, error) {
+	all, err := h.Snapshots(namespace, time.Unix(0, 0))
+	if err != nil {
+		return 0, err
+	}
+	var drop, kept []*Snapshot
+	for _, snapshot := range all {
+		if retention.MaxAge > 0 && now.Sub(snapshot.At()) > retention.MaxAge {
+			drop = append(drop, snapshot)
+			continue
+		}
+		kept = append(kept, snapshot)
+	}
+	if retention.Resolution > 0 {
+		var downsampled []*Snapshot
+		for i := 0; i < len(kept); {
+			if now.Sub(kept[i].At()) <= retention.Recent {
+				downsampled = append(downsampled, kept[i:]...)
+				break
+			}
+			bucket := kept[i].At().Truncate(retention.Resolution)
+			end := i + 1
+			for end < len(kept) && now.Sub(kept[end].At()) > retention.Recent && kept[end].At().Truncate(retention.Resolution).Equal(bucket) {
+				end++
+			}
+			if end-i == 1 {
+				downsampled = append(downsampled, kept[i])
+				i = end
+				continue
+			}
+			merged := kept[end-1]
+			if retention.Merge != nil {
+				merged, err = retention.Merge(kept[i:end])
+				if err != nil {
+					return 0, err
+				}
+				err = h.Save(merged)
+				if err != nil {
+					return 0, err
+				}
+			}
+			for _, snapshot := range kept[i:end] {
+				if snapshot.Time != merged.Time {
+					drop = append(drop, snapshot)
+				}
+			}
+			downsampled = append(downsampled, merged)
+			i = end
+		}
+		kept = downsampled
+	}
+	if retention.MaxCount > 0 && len(kept) > retention.MaxCount {
+		drop = append(drop, kept[:len(kept)-retention.MaxCount]...)
+	}
+	for _, snapshot := range drop {
+		err = h.items.Delete(snapshot)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(drop), nil
+}
+
+// mergeBandwidth averages bandwidth samples into a snapshot taken with the
+// latest one
+func mergeBandwidth(samples []*Snapshot) (*Snapshot, error) {
+	var merged client.Bandwidth
+	for _, snapshot := range samples {
+		var sample client.Bandwidth
+		err := snapshot.Decode(&sample)
+		if err != nil {
+			return nil, err
+		}
+		merged.Incoming += sample.Incoming
+		merged.Outgoing += sample.Outgoing
+		merged.Time = sample.Time
+	}
+	merged.Incoming /= float64(len(samples))
+	merged.Outgoing /= float64(len(samples))
+	latest := samples[len(samples)-1]
+	return NewSnapshot(&merged, latest.At())
+}
+
+// RestoreBandwidth fills the bandwidth history with the samples of the last
+// BandwidthHistoryLength
+func (h *History) RestoreBandwidth(into *BandwidthHistory) error {
+	saved, err := h.Snapshots(new(client.Bandwidth).GetNamespace(), h.now().Add(-BandwidthHistoryLength))
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range saved {
+		var sample client.Bandwidth
+		err = snapshot.Decode(&sample)
+		if err != nil {
+			return err
+		}
+		// samples saved without a time were taken when they were saved
+		if sample.Time == 0 {
+			sample.Time = snapshot.At().Unix()
+		}
+		into.Add(sample)
+	}
+	return nil
+}
+
+// RestorePeerEvents adds the joins and leaves saved before the page was
+// reloaded to the peer tracker
+func (h *History) RestorePeerEvents(into *PeerTracker) error {
+	saved, err := h.Snapshots(new(PeerEvent).GetNamespace(), time.Unix(0, 0))
+	if err != nil {
+		return err
+	}
+	events := make([]PeerEvent, 0, len(saved))
+	for _, snapshot := range saved {
+		var event PeerEvent
+		err = snapshot.Decode(&event)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	into.Restore(events)
+	return nil
+}
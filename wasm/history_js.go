@@ -0,0 +1,76 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"syscall/js"
+	"time"
+)
+
+// OpenHistory opens the history kept in IndexedDB, restores the bandwidth
+// samples and peer events saved before the page was reloaded and starts
+// writing new snapshots. The dashboard runs without a history if IndexedDB
+// is missing.
+func OpenHistory() {
+	objects, err := OpenIndexedDB(HistoryDatabase)
+	if err != nil {
+		log.Warn("History is not persisted: ", err.Error())
+		return
+	}
+	history := NewHistory(NewItemStore(objects))
+	err = history.RestoreBandwidth(bandwidthHistory)
+	if err != nil {
+		log.Error("Error in restoring Bandwidth in OpenHistory: ", err.Error())
+	}
+	err = history.RestorePeerEvents(peerTracker)
+	if err != nil {
+		log.Error("Error in restoring Peer Events in OpenHistory: ", err.Error())
+	}
+	dashboardHistory = history
+	go history.Run(context.Background())
+}
+
+// GetHistory resolves the snapshots of a namespace, "Bandwidth",
+// "UserBalance", "UptimePercentage", "Event" or "PeerEvent", taken since a time in
+// milliseconds, or all of them, as [{time, item}]
+func GetHistory() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("GetHistory needs a namespace"))
+		}
+		namespace := args[0].String()
+		since := time.Unix(0, 0)
+		if len(args) > 1 && args[1].Type() == js.TypeNumber {
+			since = time.Unix(0, int64(args[1].Float())*int64(time.Millisecond))
+		}
+		handler := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			resolve := args[0]
+			reject := args[1]
+			go func() {
+				if dashboardHistory == nil {
+					resolve.Invoke(js.Global().Get("Array").New())
+					return
+				}
+				saved, err := dashboardHistory.Snapshots(namespace, since)
+				if err != nil {
+					log.Error("Error in reading the history in GetHistory: ", err.Error())
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				val, err := json.Marshal(saved)
+				if err != nil {
+					log.Error("Error in marshalling the history in GetHistory: ", err.Error())
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				resolve.Invoke(js.Global().Get("JSON").Call("parse", string(val)))
+			}()
+			return nil
+		})
+		return js.Global().Get("Promise").New(handler)
+	})
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+	store "github.com/StreamSpace/ss-store"
+)
+
+// newTestHistory returns a history in memory whose clock is at now
+func newTestHistory(now time.Time) *History {
+	h := NewHistory(NewItemStore(NewMemoryObjects()))
+	h.now = func() time.Time { return now }
+	return h
+}
+
+func saveBandwidth(t *testing.T, h *History, at time.Time, in, out float64) {
+	t.Helper()
+	snapshot, err := NewSnapshot(&client.Bandwidth{Incoming: in, Outgoing: out, Time: at.Unix()}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Save(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	at := time.Unix(1609459200, 5e8)
+	snapshot, err := NewSnapshot(&client.Balance{Balance: 12.5}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.GetNamespace() != "UserBalance" || snapshot.GetId() != "00000001609459200500" || !snapshot.At().Equal(at) {
+		t.Errorf("got %s/%s at %s", snapshot.GetNamespace(), snapshot.GetId(), snapshot.At())
+	}
+	later, _ := NewSnapshot(&client.Balance{}, at.Add(time.Hour*24*365*100))
+	if later.GetId() <= snapshot.GetId() {
+		t.Errorf("ids don't sort by time: %s before %s", later.GetId(), snapshot.GetId())
+	}
+	val, err := snapshot.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val) != `{"time":1609459200500,"item":{"balance":12.5}}` {
+		t.Errorf("got %s", val)
+	}
+	read := snapshots("UserBalance").Factory().(*Snapshot)
+	err = read.Unmarshal(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var balance client.Balance
+	err = read.Decode(&balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Namespace != "UserBalance" || balance.Balance != 12.5 {
+		t.Errorf("got %s %+v", read.Namespace, balance)
+	}
+}
+
+func TestHistoryRecord(t *testing.T) {
+	now := time.Unix(1609459200, 0)
+	h := newTestHistory(now)
+	h.Record(&client.Balance{Balance: 1})
+	// a snapshot taken at the same time replaces the previous one
+	h.Record(&client.Balance{Balance: 2})
+	h.Record(&client.Settlement{Cycle: 1})
+	h.Flush()
+	saved, err := h.Snapshots("UserBalance", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || string(saved[0].Item) != `{"balance":2}` {
+		t.Errorf("got %v", saved)
+	}
+	if saved, _ := h.Snapshots("Settlement", time.Unix(0, 0)); len(saved) != 0 {
+		t.Errorf("recorded a namespace without retention: %v", saved)
+	}
+
+	for i := 0; i < HistoryQueueSize+10; i++ {
+		h.now = func() time.Time { return now.Add(time.Duration(i+1) * time.Second) }
+		h.Record(&client.Balance{Balance: float64(i)})
+	}
+	h.Flush()
+	if saved, _ := h.Snapshots("UserBalance", now.Add(time.Second)); len(saved) != HistoryQueueSize {
+		t.Errorf("got %d snapshots from a full queue, want %d", len(saved), HistoryQueueSize)
+	}
+	if saved, _ := h.Snapshots("UserBalance", now.Add(time.Minute)); len(saved) != HistoryQueueSize-59 {
+		t.Errorf("got %d snapshots since a minute, want %d", len(saved), HistoryQueueSize-59)
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	registerHandlers.Do(RegisterDefaultTopicHandlers)
+	useFakeDOM()
+	RecordHistory(&client.Balance{Balance: 1})
+
+	now := time.Now()
+	dashboardHistory = newTestHistory(now)
+	defer func() { dashboardHistory = nil }()
+	err := DispatchEvent(readFixture(t, "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = StatusEvent(readFixture(t, "status.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dashboardHistory.Flush()
+	for _, tc := range []struct {
+		namespace string
+		item      store.Serializable
+		want      string
+	}{
+		{"Event", &client.Event{}, `"topic":"Balance"`},
+		{"UserBalance", &client.Balance{}, `{"balance":-0.5}`},
+		{"UptimePercentage", &client.UptimePercentage{}, `{"Status":true,"Percentage":100,"SecondsFromInception":86400,"Timestamp":1609545600}`},
+	} {
+		saved, err := dashboardHistory.Snapshots(tc.namespace, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(saved) != 1 {
+			t.Errorf("%s: got %d snapshots", tc.namespace, len(saved))
+			continue
+		}
+		if err := saved[0].Decode(tc.item); err != nil {
+			t.Errorf("%s: %s", tc.namespace, err.Error())
+		}
+		if got := string(saved[0].Item); !strings.Contains(got, tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.namespace, got, tc.want)
+		}
+	}
+}
+
+func TestCompactBandwidth(t *testing.T) {
+	now := time.Date(2021, 1, 10, 12, 0, 0, 0, time.UTC)
+	h := newTestHistory(now)
+	// past the maximum age
+	saveBandwidth(t, h, now.Add(-8*24*time.Hour), 1, 1)
+	// two days ago, three samples in one 5 minute span and one in the next
+	old := now.Add(-48 * time.Hour)
+	saveBandwidth(t, h, old, 100, 10)
+	saveBandwidth(t, h, old.Add(time.Minute), 200, 20)
+	saveBandwidth(t, h, old.Add(2*time.Minute), 600, 60)
+	saveBandwidth(t, h, old.Add(5*time.Minute), 50, 5)
+	// recent samples are kept as they are
+	saveBandwidth(t, h, now.Add(-time.Hour), 7, 7)
+	saveBandwidth(t, h, now.Add(-time.Hour+5*time.Second), 8, 8)
+
+	removed, err := h.CompactNamespace("Bandwidth", Retentions["Bandwidth"], now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 3 {
+		t.Errorf("removed %d snapshots, want 3", removed)
+	}
+	saved, err := h.Snapshots("Bandwidth", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []client.Bandwidth{
+		{Incoming: 300, Outgoing: 30, Time: old.Add(2 * time.Minute).Unix()},
+		{Incoming: 50, Outgoing: 5, Time: old.Add(5 * time.Minute).Unix()},
+		{Incoming: 7, Outgoing: 7, Time: now.Add(-time.Hour).Unix()},
+		{Incoming: 8, Outgoing: 8, Time: now.Add(-time.Hour + 5*time.Second).Unix()},
+	}
+	if len(saved) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(saved), len(want))
+	}
+	for i, snapshot := range saved {
+		var sample client.Bandwidth
+		if err := snapshot.Decode(&sample); err != nil {
+			t.Fatal(err)
+		}
+		if sample != want[i] || snapshot.At().Unix() != want[i].Time {
+			t.Errorf("%d: got %+v at %s, want %+v", i, sample, snapshot.At(), want[i])
+		}
+	}
+
+	// compacting again changes nothing
+	removed, err = h.CompactNamespace("Bandwidth", Retentions["Bandwidth"], now)
+	if err != nil || removed != 0 {
+		t.Errorf("compacted again: removed %d, %v", removed, err)
+	}
+}
+
+func TestCompactRetention(t *testing.T) {
+	now := time.Date(2021, 1, 10, 12, 0, 0, 0, time.UTC)
+	h := newTestHistory(now)
+	for i := 0; i < 6; i++ {
+		snapshot, _ := NewSnapshot(&client.Balance{Balance: float64(i)}, now.Add(-72*time.Hour+time.Duration(i)*20*time.Minute))
+		if err := h.Save(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the latest balance of each hour is kept past the recent ones
+	removed, err := h.CompactNamespace("UserBalance", Retention{MaxAge: 96 * time.Hour, Recent: time.Hour, Resolution: time.Hour}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := h.Snapshots("UserBalance", time.Unix(0, 0))
+	if removed != 4 || len(saved) != 2 || string(saved[0].Item) != `{"balance":2}` || string(saved[1].Item) != `{"balance":5}` {
+		t.Errorf("removed %d, kept %v", removed, saved)
+	}
+
+	// the oldest are dropped past the maximum count
+	removed, err = h.CompactNamespace("UserBalance", Retention{MaxCount: 1}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _ = h.Snapshots("UserBalance", time.Unix(0, 0))
+	if removed != 1 || len(saved) != 1 || string(saved[0].Item) != `{"balance":5}` {
+		t.Errorf("removed %d, kept %v", removed, saved)
+	}
+
+	if err := h.Compact(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRestoreBandwidth(t *testing.T) {
+	now := time.Now()
+	h := newTestHistory(now)
+	saveBandwidth(t, h, now.Add(-BandwidthHistoryLength-time.Minute), 1, 1)
+	saveBandwidth(t, h, now.Add(-time.Minute), 2, 2)
+	saveBandwidth(t, h, now, 3, 3)
+	restored := NewBandwidthHistory(10)
+	err := h.RestoreBandwidth(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := restored.Since(time.Unix(0, 0))
+	if len(samples) != 2 || samples[0].Incoming != 2 || samples[1].Incoming != 3 {
+		t.Errorf("got %+v", samples)
+	}
+}
+
+func TestRestoreBandwidthTimes(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	h := newTestHistory(start)
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		h.now = func() time.Time { return at }
+		sample := &client.Bandwidth{Incoming: float64(i)}
+		// older samples were saved without their time
+		if i%2 == 0 {
+			sample.Time = at.Unix()
+		}
+		h.Record(sample)
+	}
+	h.Flush()
+	h.now = func() time.Time { return start.Add(time.Hour) }
+	restored := NewBandwidthHistory(10)
+	err := h.RestoreBandwidth(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := restored.Since(time.Unix(0, 0))
+	if len(samples) != 4 {
+		t.Fatalf("got %d samples, want 4: %+v", len(samples), samples)
+	}
+	for i, sample := range samples {
+		if want := start.Add(time.Duration(i) * time.Minute).Unix(); sample.Time != want || sample.Incoming != float64(i) {
+			t.Errorf("sample %d: got %+v, want time %d", i, sample, want)
+		}
+	}
+}
+
+func TestRestorePeerEvents(t *testing.T) {
+	now := time.Unix(1609459200, 0)
+	h := newTestHistory(now)
+	// events of the same update are all kept
+	h.Record(&PeerEvent{PeerID: "QmA", Change: PeerLeft, Time: now})
+	h.Record(&PeerEvent{PeerID: "QmB", Change: PeerJoined, Time: now})
+	h.Record(&PeerEvent{PeerID: "QmC", Change: PeerJoined, Time: now})
+	h.Flush()
+	restored := NewPeerTracker()
+	err := h.RestorePeerEvents(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := changes(restored.Events(TimelineLength)), []string{"joined QmC", "joined QmB", "left QmA"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	store "github.com/StreamSpace/ss-store"
+)
+
+const (
+	// HistoryDatabase is the IndexedDB database the history is kept in
+	HistoryDatabase = "hive-history"
+
+	indexedDBVersion = 1
+	itemsStore       = "items"
+	namespaceIndex   = "namespace"
+)
+
+// IndexedDB is an ObjectStore kept in one IndexedDB object store, keyed by
+// [namespace, id] with an index on the namespace. Its methods block until
+// IndexedDB answers, so they must not be called from a js callback.
+type IndexedDB struct {
+	db js.Value
+}
+
+var _ ObjectStore = (*IndexedDB)(nil)
+
+// OpenIndexedDB opens or creates the database with name
+func OpenIndexedDB(name string) (*IndexedDB, error) {
+	factory := js.Global().Get("indexedDB")
+	if !factory.Truthy() {
+		return nil, errors.New("IndexedDB is not available")
+	}
+	req := factory.Call("open", name, indexedDBVersion)
+	upgrade := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		db := req.Get("result")
+		if !db.Get("objectStoreNames").Call("contains", itemsStore).Bool() {
+			items := db.Call("createObjectStore", itemsStore, map[string]interface{}{
+				"keyPath": []interface{}{"namespace", "id"},
+			})
+			items.Call("createIndex", namespaceIndex, "namespace")
+		}
+		return nil
+	})
+	defer upgrade.Release()
+	req.Set("onupgradeneeded", upgrade)
+	db, err := awaitRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %s", name, err.Error())
+	}
+	return &IndexedDB{db: db}, nil
+}
+
+// awaitRequest blocks until an IDBRequest succeeds or fails and returns its
+// result
+func awaitRequest(req js.Value) (js.Value, error) {
+	done := make(chan error, 1)
+	onSuccess := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- nil
+		return nil
+	})
+	defer onSuccess.Release()
+	onError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- errors.New(js.Global().Get("String").Invoke(req.Get("error")).String())
+		return nil
+	})
+	defer onError.Release()
+	req.Set("onsuccess", onSuccess)
+	req.Set("onerror", onError)
+	err := <-done
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return req.Get("result"), nil
+}
+
+func (d *IndexedDB) objects(mode string) js.Value {
+	return d.db.Call("transaction", itemsStore, mode).Call("objectStore", itemsStore)
+}
+
+func (d *IndexedDB) Get(namespace, id string) (*Record, error) {
+	result, err := awaitRequest(d.objects("readonly").Call("get", []interface{}{namespace, id}))
+	if err != nil {
+		return nil, err
+	}
+	if result.IsUndefined() {
+		return nil, store.ErrRecordNotFound
+	}
+	record := recordFromJS(result)
+	return &record, nil
+}
+
+func (d *IndexedDB) Put(record Record) error {
+	_, err := awaitRequest(d.objects("readwrite").Call("put", map[string]interface{}{
+		"namespace": record.Namespace,
+		"id":        record.Id,
+		"value":     string(record.Value),
+		"created":   record.Created,
+		"updated":   record.Updated,
+	}))
+	return err
+}
+
+func (d *IndexedDB) Delete(namespace, id string) error {
+	_, err := awaitRequest(d.objects("readwrite").Call("delete", []interface{}{namespace, id}))
+	return err
+}
+
+func (d *IndexedDB) GetAll(namespace string) ([]Record, error) {
+	result, err := awaitRequest(d.objects("readonly").Call("index", namespaceIndex).Call("getAll", namespace))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, result.Length())
+	for i := range records {
+		records[i] = recordFromJS(result.Index(i))
+	}
+	return records, nil
+}
+
+func (d *IndexedDB) Close() error {
+	d.db.Call("close")
+	return nil
+}
+
+func recordFromJS(value js.Value) Record {
+	return Record{
+		Namespace: value.Get("namespace").String(),
+		Id:        value.Get("id").String(),
+		Value:     []byte(value.Get("value").String()),
+		Created:   int64(value.Get("created").Float()),
+		Updated:   int64(value.Get("updated").Float()),
+	}
+}
@@ -0,0 +1,180 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	store "github.com/StreamSpace/ss-store"
+)
+
+// ErrRecordExists is returned by Create for an item that is already stored
+var ErrRecordExists = errors.New("record already exists")
+
+// Record is an item as an ObjectStore keeps it
+type Record struct {
+	Namespace string
+	Id        string
+	Value     []byte
+	Created   int64
+	Updated   int64
+}
+
+// ObjectStore keeps records by namespace and id. The browser implementation
+// is backed by IndexedDB in indexeddb_js.go.
+type ObjectStore interface {
+	// Get returns the record with the namespace and id, or
+	// store.ErrRecordNotFound.
+	Get(namespace, id string) (*Record, error)
+	// Put adds or replaces a record.
+	Put(record Record) error
+	// Delete removes a record, it is not an error if there is none.
+	Delete(namespace, id string) error
+	// GetAll returns the records of a namespace ordered by id.
+	GetAll(namespace string) ([]Record, error)
+	Close() error
+}
+
+// ItemStore is the ss-store Store of the dashboard, it keeps serialized
+// items in an ObjectStore. Items implementing store.TimeTracker get their
+// created and updated times set.
+type ItemStore struct {
+	objects ObjectStore
+	now     func() time.Time
+}
+
+var _ store.Store = (*ItemStore)(nil)
+
+func NewItemStore(objects ObjectStore) *ItemStore {
+	return &ItemStore{objects: objects, now: time.Now}
+}
+
+func serializable(item store.Item) (store.Serializable, error) {
+	s, ok := item.(store.Serializable)
+	if !ok {
+		return nil, fmt.Errorf("%T is not serializable", item)
+	}
+	return s, nil
+}
+
+// Create stores a new item, it fails with ErrRecordExists if there is one
+// with the same namespace and id
+func (s *ItemStore) Create(item store.Item) error {
+	_, err := s.objects.Get(item.GetNamespace(), item.GetId())
+	if err == nil {
+		return ErrRecordExists
+	}
+	if err != store.ErrRecordNotFound {
+		return err
+	}
+	now := s.now().Unix()
+	return s.put(item, now, now)
+}
+
+// Read fills item with the stored one of the same namespace and id
+func (s *ItemStore) Read(item store.Item) error {
+	val, err := serializable(item)
+	if err != nil {
+		return err
+	}
+	record, err := s.objects.Get(item.GetNamespace(), item.GetId())
+	if err != nil {
+		return err
+	}
+	err = val.Unmarshal(record.Value)
+	if err != nil {
+		return err
+	}
+	setTimes(item, record)
+	return nil
+}
+
+// Update replaces a stored item, keeping the time it was created
+func (s *ItemStore) Update(item store.Item) error {
+	record, err := s.objects.Get(item.GetNamespace(), item.GetId())
+	if err != nil {
+		return err
+	}
+	return s.put(item, record.Created, s.now().Unix())
+}
+
+func (s *ItemStore) put(item store.Item, created, updated int64) error {
+	val, err := serializable(item)
+	if err != nil {
+		return err
+	}
+	if tracker, ok := item.(store.TimeTracker); ok {
+		tracker.SetCreated(created)
+		tracker.SetUpdated(updated)
+	}
+	value, err := val.Marshal()
+	if err != nil {
+		return err
+	}
+	return s.objects.Put(Record{
+		Namespace: item.GetNamespace(),
+		Id:        item.GetId(),
+		Value:     value,
+		Created:   created,
+		Updated:   updated,
+	})
+}
+
+// Delete removes a stored item
+func (s *ItemStore) Delete(item store.Item) error {
+	return s.objects.Delete(item.GetNamespace(), item.GetId())
+}
+
+// List returns the items of the namespace of the factory's items. Items are
+// ordered by id unless sorted otherwise, and paged when opt has a limit.
+func (s *ItemStore) List(factory store.Factory, opt store.ListOpt) (store.Items, error) {
+	records, err := s.objects.GetAll(factory.Factory().GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	switch opt.Sort {
+	case store.SortCreatedAsc:
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Created < records[j].Created })
+	case store.SortCreatedDesc:
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Created > records[j].Created })
+	case store.SortUpdatedAsc:
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Updated < records[j].Updated })
+	case store.SortUpdatedDesc:
+		sort.SliceStable(records, func(i, j int) bool { return records[i].Updated > records[j].Updated })
+	}
+	items := store.Items{}
+	skip := opt.Page * opt.Limit
+	for i := range records {
+		item := factory.Factory()
+		err = item.Unmarshal(records[i].Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %s", records[i].Namespace, records[i].Id, err.Error())
+		}
+		setTimes(item, &records[i])
+		if opt.Filter != nil && !opt.Filter.Compare(item) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		items = append(items, item)
+		if opt.Limit > 0 && int64(len(items)) == opt.Limit {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (s *ItemStore) Close() error {
+	return s.objects.Close()
+}
+
+func setTimes(item store.Item, record *Record) {
+	if tracker, ok := item.(store.TimeTracker); ok {
+		tracker.SetCreated(record.Created)
+		tracker.SetUpdated(record.Updated)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	store "github.com/StreamSpace/ss-store"
+)
+
+// MemoryObjects is an ObjectStore kept in memory, standing in for IndexedDB
+type MemoryObjects struct {
+	mtx     sync.Mutex
+	records map[string]map[string]Record
+}
+
+func NewMemoryObjects() *MemoryObjects {
+	return &MemoryObjects{records: make(map[string]map[string]Record)}
+}
+
+func (m *MemoryObjects) Get(namespace, id string) (*Record, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	record, found := m.records[namespace][id]
+	if !found {
+		return nil, store.ErrRecordNotFound
+	}
+	return &record, nil
+}
+
+func (m *MemoryObjects) Put(record Record) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.records[record.Namespace] == nil {
+		m.records[record.Namespace] = make(map[string]Record)
+	}
+	m.records[record.Namespace][record.Id] = record
+	return nil
+}
+
+func (m *MemoryObjects) Delete(namespace, id string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.records[namespace], id)
+	return nil
+}
+
+func (m *MemoryObjects) GetAll(namespace string) ([]Record, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	records := make([]Record, 0, len(m.records[namespace]))
+	for _, record := range m.records[namespace] {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Id < records[j].Id })
+	return records, nil
+}
+
+func (m *MemoryObjects) Close() error {
+	return nil
+}
+
+type trackedItem struct {
+	Namespace string
+	Id        string
+	Text      string
+	Created   int64 `json:"-"`
+	Updated   int64 `json:"-"`
+}
+
+func (t *trackedItem) GetNamespace() string          { return t.Namespace }
+func (t *trackedItem) GetId() string                 { return t.Id }
+func (t *trackedItem) Marshal() ([]byte, error)      { return json.Marshal(t) }
+func (t *trackedItem) Unmarshal(val []byte) error    { return json.Unmarshal(val, t) }
+func (t *trackedItem) SetCreated(unixTime int64)     { t.Created = unixTime }
+func (t *trackedItem) GetCreated() int64             { return t.Created }
+func (t *trackedItem) SetUpdated(unixTime int64)     { t.Updated = unixTime }
+func (t *trackedItem) GetUpdated() int64             { return t.Updated }
+func (t *trackedItem) Factory() store.SerializedItem { return &trackedItem{Namespace: t.Namespace} }
+
+type textFilter string
+
+func (f textFilter) Compare(item store.SerializedItem) bool {
+	return item.(*trackedItem).Text != string(f)
+}
+
+// clockStore returns an ItemStore whose clock advances a second on every call
+func clockStore() *ItemStore {
+	s := NewItemStore(NewMemoryObjects())
+	now := time.Unix(1609459200, 0)
+	s.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return s
+}
+
+func TestItemStoreCRUD(t *testing.T) {
+	s := clockStore()
+	item := &trackedItem{Namespace: "Test", Id: "1", Text: "first"}
+	if err := s.Create(item); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(&trackedItem{Namespace: "Test", Id: "1"}); err != ErrRecordExists {
+		t.Errorf("created an item twice: %v", err)
+	}
+	if err := s.Create(&trackedItem{Namespace: "Other", Id: "1", Text: "other"}); err != nil {
+		t.Errorf("ids are not kept per namespace: %v", err)
+	}
+	item.Text = "second"
+	if err := s.Update(item); err != nil {
+		t.Fatal(err)
+	}
+	read := &trackedItem{Namespace: "Test", Id: "1"}
+	if err := s.Read(read); err != nil {
+		t.Fatal(err)
+	}
+	if read.Text != "second" || read.Created != 1609459201 || read.Updated != 1609459203 {
+		t.Errorf("got %+v", read)
+	}
+	if err := s.Update(&trackedItem{Namespace: "Test", Id: "2"}); err != store.ErrRecordNotFound {
+		t.Errorf("updated a missing item: %v", err)
+	}
+	if err := s.Delete(item); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Read(read); err != store.ErrRecordNotFound {
+		t.Errorf("read a deleted item: %v", err)
+	}
+	if err := s.Read(&trackedItem{Namespace: "Other", Id: "1"}); err != nil {
+		t.Errorf("delete reached another namespace: %v", err)
+	}
+}
+
+func TestItemStoreList(t *testing.T) {
+	s := clockStore()
+	// created c, b, a and updated b last
+	for _, id := range []string{"c", "b", "a"} {
+		if err := s.Create(&trackedItem{Namespace: "Test", Id: id, Text: "text " + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Create(&trackedItem{Namespace: "Other", Id: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(&trackedItem{Namespace: "Test", Id: "b", Text: "text b"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		opt  store.ListOpt
+		want string
+	}{
+		{"natural", store.ListOpt{}, "abc"},
+		{"created asc", store.ListOpt{Sort: store.SortCreatedAsc}, "cba"},
+		{"created desc", store.ListOpt{Sort: store.SortCreatedDesc}, "abc"},
+		{"updated asc", store.ListOpt{Sort: store.SortUpdatedAsc}, "cab"},
+		{"updated desc", store.ListOpt{Sort: store.SortUpdatedDesc}, "bac"},
+		{"first page", store.ListOpt{Limit: 2}, "ab"},
+		{"second page", store.ListOpt{Page: 1, Limit: 2}, "c"},
+		{"past the end", store.ListOpt{Page: 2, Limit: 2}, ""},
+		{"filter", store.ListOpt{Filter: textFilter("text b")}, "ac"},
+		{"filtered page", store.ListOpt{Page: 1, Limit: 1, Filter: textFilter("text a")}, "c"},
+	} {
+		items, err := s.List(&trackedItem{Namespace: "Test"}, tc.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, item := range items {
+			got += item.GetId()
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	objects := s.objects.(*MemoryObjects)
+	objects.Put(Record{Namespace: "Test", Id: "z", Value: []byte(`{"`)})
+	if _, err := s.List(&trackedItem{Namespace: "Test"}, store.ListOpt{}); err == nil {
+		t.Error("listed a malformed record")
+	}
+	if err := s.Create(notSerializable{}); err == nil {
+		t.Errorf("created %T", notSerializable{})
+	}
+}
+
+type notSerializable struct{}
+
+func (notSerializable) GetNamespace() string { return "Test" }
+func (notSerializable) GetId() string        { return "x" }
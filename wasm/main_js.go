@@ -0,0 +1,136 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+// loader wraps a startup load in a js.Func for pages that call it directly
+func loader(name string, load func() error) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go func() {
+			err := load()
+			if err != nil {
+				log.Errorf("Error in %s: %s", name, err.Error())
+			}
+		}()
+		return nil
+	})
+}
+
+func GetID() js.Func {
+	return loader("GetID", LoadID)
+}
+
+func SetEarningDropDown() js.Func {
+	return loader("SetEarningDropDown", LoadEarningDropDown)
+}
+
+func GetStorageLocation() js.Func {
+	return loader("GetStorageLocation", LoadStorageLocation)
+}
+
+func GetProfile() js.Func {
+	return loader("GetProfile", LoadProfile)
+}
+
+func GetBandwidth() js.Func {
+	return loader("GetBandwidth", LoadBandwidth)
+}
+
+// GetEarning resolves the earnings summary of a device, or of every device
+// when called without one, and renders its totals
+func GetEarning() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		device := client.AllDevices
+		if len(args) > 0 && args[0].Type() == js.TypeString && args[0].String() != "" {
+			device = args[0].String()
+		}
+		handler := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			resolve := args[0]
+			reject := args[1]
+			go func() {
+				summary, err := LoadEarnings(device)
+				if err != nil {
+					log.Error("Error in getting Net Earnings in GetEarning: ", err.Error())
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				val, err := json.Marshal(summary)
+				if err != nil {
+					log.Error("Error in marshalling Net Earnings in GetEarning: ", err.Error())
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				log.Debug("Sending details to CreateGraph from GetEarning")
+				resolve.Invoke(js.Global().Get("JSON").Call("parse", string(val)))
+			}()
+			return nil
+		})
+		promiseConstructor := js.Global().Get("Promise")
+		return promiseConstructor.New(handler)
+	})
+}
+
+func GetUptime() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		RenderUptime()
+		return nil
+	})
+}
+
+func GetVersion() js.Func {
+	return loader("GetVersion", LoadVersion)
+}
+
+func main() {
+	LoadLogLevel()
+	LoadNotifications()
+	RegisterDefaultTopicHandlers()
+	js.Global().Set("SetSwrmPortNumber", SetSwrmPortNumber())
+	js.Global().Set("SetWebsocketPortNumber", SetWebsocketPortNumber())
+	js.Global().Set("GetSettings", GetSettings())
+	js.Global().Set("ModifyStorageSize", ModifyStorageSize())
+	js.Global().Set("GetStatus", GetStatus())
+	js.Global().Set("GetConfig", GetConfig())
+	js.Global().Set("VerifyPort", VerifyPort())
+	js.Global().Set("SetEarningDropDown", SetEarningDropDown())
+	js.Global().Set("GetVersion", GetVersion())
+	js.Global().Set("GetProfile", GetProfile())
+	js.Global().Set("GetUptime", GetUptime())
+	js.Global().Set("GetBandwidth", GetBandwidth())
+	js.Global().Set("GetBandwidthHistory", GetBandwidthHistory())
+	js.Global().Set("ToggleBandwidthHistory", ToggleBandwidthHistory())
+	js.Global().Set("SelectBandwidthWindow", SelectBandwidthWindow())
+	js.Global().Set("GetHistory", GetHistory())
+	js.Global().Set("SortPeers", SortPeers())
+	js.Global().Set("FilterPeers", FilterPeers())
+	js.Global().Set("PeerAction", PeerAction())
+	js.Global().Set("DialPeer", DialPeer())
+	js.Global().Set("GetPeerChurn", GetPeerChurn())
+	js.Global().Set("TogglePeerTimeline", TogglePeerTimeline())
+	js.Global().Set("GetStorageLocation", GetStorageLocation())
+	js.Global().Set("GetID", GetID())
+	js.Global().Set("GetEarning", GetEarning())
+	js.Global().Set("ExportEarnings", ExportEarnings())
+	js.Global().Set("Events", Events())
+	js.Global().Set("Start", Start())
+	js.Global().Set("SetEndpoint", SetEndpoint())
+	js.Global().Set("OnHiveEvent", OnHiveEvent())
+	js.Global().Set("Login", Login())
+	js.Global().Set("Logout", Logout())
+	js.Global().Set("ToggleNotifications", ToggleNotifications())
+	js.Global().Set("ClearNotifications", ClearNotifications())
+	js.Global().Set("GetNotifications", GetNotifications())
+	js.Global().Set("SetLogLevel", SetLogLevel())
+	hive.SetUnauthorizedHandler(ShowLogin)
+	hive.SetErrorHandler(NotifyError)
+	go Connect()
+	<-make(chan bool)
+}
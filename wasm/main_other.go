@@ -0,0 +1,17 @@
+//go:build !js
+// +build !js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// main only exists so the package builds and tests natively, the dashboard
+// itself runs in the browser.
+func main() {
+	fmt.Println("hive-wasm-client runs in the browser, build it with GOOS=js GOARCH=wasm")
+	os.Exit(1)
+}
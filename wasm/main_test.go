@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestHumanize(t *testing.T) {
+	for _, tc := range []struct {
+		value float64
+		want  string
+	}{
+		{0, "0.0 B"},
+		{1023, "1023.0 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{1048576, "1.0 MB"},
+		{1073741824, "1.0 GB"},
+		{1099511627775, "1024.0 GB"},
+		{1099511627776, "1.0 TB"},
+		{5 * 1099511627776, "5.0 TB"},
+		{2048 * 1099511627776, "2048.0 TB"},
+		{-512, "-512.0 B"},
+		{-1536, "-1.5 KB"},
+		{-3 * 1099511627776, "-3.0 TB"},
+		{0.4, "0.4 B"},
+	} {
+		if got := Humanize(tc.value); got != tc.want {
+			t.Errorf("Humanize(%v): got %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRenderEarnings(t *testing.T) {
+	fake := useFakeDOM("EarnedCycle", "DownloadedCycle", "ServedCycle")
+	RenderEarnings(&client.EarningsSummary{
+		BillingCycles: []string{"02-01-2021", "01-01-2021"},
+		Total:         client.Earning{Earned: 1.65, Served: 5 * 1099511627776, Download: 1536},
+		Average:       client.Earning{Earned: 0.825, Served: 2.5 * 1099511627776, Download: 768},
+	})
+	for _, tc := range []struct {
+		id, property, want string
+	}{
+		{"EarnedCycle", "innerHTML", "1.65000000 SWRM"},
+		{"EarnedCycle", "title", "Average 0.82500000 SWRM per cycle over 2 billing cycles"},
+		{"ServedCycle", "innerHTML", "5.0 TB"},
+		{"ServedCycle", "title", "Average 2.5 TB per cycle over 2 billing cycles"},
+		{"DownloadedCycle", "innerHTML", "1.5 KB"},
+		{"DownloadedCycle", "title", "Average 768.0 B per cycle over 2 billing cycles"},
+	} {
+		if got, _ := fake.Property(tc.id, tc.property); got != tc.want {
+			t.Errorf("%s.%s: got %q, want %q", tc.id, tc.property, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+type NotificationLevel string
+
+const (
+	LevelError   NotificationLevel = "error"
+	LevelWarning NotificationLevel = "warning"
+	LevelSuccess NotificationLevel = "success"
+	LevelInfo    NotificationLevel = "info"
+)
+
+const (
+	// ToastTimeout is how long a toast stays on screen, errors stay twice as long
+	ToastTimeout = 5 * time.Second
+	// NotificationHistorySize is the number of notifications kept in the history panel
+	NotificationHistorySize = 100
+	// NotificationStorageKey is the localStorage key the history is persisted under
+	NotificationStorageKey = "hiveNotifications"
+)
+
+// Notification is a toast raised by the dashboard, kept in the history panel
+type Notification struct {
+	ID      int64             `json:"id"`
+	Level   NotificationLevel `json:"level"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Details string            `json:"details,omitempty"`
+	Time    int64             `json:"time"`
+	Count   int               `json:"count"`
+}
+
+var (
+	notifyMtx     sync.Mutex
+	notifications []*Notification
+	unreadCount   int
+
+	// showToast and renderNotifications draw the toasts and the history
+	// panel, they are set on js
+	showToast           = func(n *Notification) {}
+	renderNotifications = func() {}
+)
+
+// Notify shows a toast and adds it to the notification history. A repeat of
+// the last notification while its toast is still shown only bumps its count,
+// so a daemon going down doesn't raise a toast per widget.
+func Notify(level NotificationLevel, title, message, details string) {
+	now := time.Now()
+	notifyMtx.Lock()
+	if len(notifications) > 0 {
+		last := notifications[len(notifications)-1]
+		if last.Level == level && last.Title == title && last.Message == message &&
+			now.Sub(time.Unix(0, last.Time*int64(time.Millisecond))) < ToastTimeout {
+			last.Count++
+			last.Time = now.UnixNano() / int64(time.Millisecond)
+			notifyMtx.Unlock()
+			saveNotifications()
+			renderNotifications()
+			return
+		}
+	}
+	n := &Notification{
+		ID:      now.UnixNano(),
+		Level:   level,
+		Title:   title,
+		Message: message,
+		Details: details,
+		Time:    now.UnixNano() / int64(time.Millisecond),
+		Count:   1,
+	}
+	notifications = append(notifications, n)
+	if len(notifications) > NotificationHistorySize {
+		notifications = notifications[len(notifications)-NotificationHistorySize:]
+	}
+	unreadCount++
+	notifyMtx.Unlock()
+	saveNotifications()
+	renderNotifications()
+	showToast(n)
+}
+
+// NotifyError raises an error toast for err, naming the failed command and
+// the daemon's details when err is a *client.Error
+func NotifyError(err error) {
+	if e, ok := err.(*client.Error); ok {
+		message := e.Message
+		if message == "" {
+			message = fmt.Sprintf("status %d", e.Status)
+		}
+		Notify(LevelError, fmt.Sprintf("hive-cli %s failed", e.Command), message, e.Details)
+		return
+	}
+	Notify(LevelError, "Error", err.Error(), "")
+}
+
+// LoadNotifications restores the notification history saved by earlier visits
+func LoadNotifications() {
+	saved, found := dom.StorageItem(NotificationStorageKey)
+	if !found {
+		return
+	}
+	var history []*Notification
+	err := json.Unmarshal([]byte(saved), &history)
+	if err != nil {
+		log.Warn("Discarding notification history: ", err.Error())
+		dom.RemoveStorageItem(NotificationStorageKey)
+		return
+	}
+	notifyMtx.Lock()
+	notifications = append(history, notifications...)
+	if len(notifications) > NotificationHistorySize {
+		notifications = notifications[len(notifications)-NotificationHistorySize:]
+	}
+	notifyMtx.Unlock()
+	renderNotifications()
+}
+
+func saveNotifications() {
+	notifyMtx.Lock()
+	buf, err := json.Marshal(notifications)
+	notifyMtx.Unlock()
+	if err != nil {
+		log.Error("Error in marshalling notifications in saveNotifications: ", err.Error())
+		return
+	}
+	dom.SetStorageItem(NotificationStorageKey, string(buf))
+}
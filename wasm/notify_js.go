@@ -0,0 +1,201 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"syscall/js"
+	"time"
+
+	logger "github.com/ipfs/go-log/v2"
+)
+
+func init() {
+	showToast = showJSToast
+	renderNotifications = renderJSNotifications
+}
+
+// notificationElement builds the markup of a toast or history entry. Text is
+// set through textContent since messages and details come from the daemon.
+func notificationElement(n *Notification, className string) js.Value {
+	jsDoc := js.Global().Get("document")
+	item := jsDoc.Call("createElement", "div")
+	item.Set("className", fmt.Sprintf("%s %s", className, n.Level))
+	title := jsDoc.Call("createElement", "div")
+	title.Set("className", "NotificationTitle_Class")
+	if n.Count > 1 {
+		title.Set("textContent", fmt.Sprintf("%s (%d)", n.Title, n.Count))
+	} else {
+		title.Set("textContent", n.Title)
+	}
+	item.Call("appendChild", title)
+	message := jsDoc.Call("createElement", "div")
+	message.Set("className", "NotificationMessage_Class")
+	message.Set("textContent", n.Message)
+	item.Call("appendChild", message)
+	if n.Details != "" {
+		details := jsDoc.Call("createElement", "div")
+		details.Set("className", "NotificationDetails_Class")
+		details.Set("textContent", n.Details)
+		item.Call("appendChild", details)
+	}
+	return item
+}
+
+func showJSToast(n *Notification) {
+	jsDoc := js.Global().Get("document")
+	if !jsDoc.Truthy() {
+		return
+	}
+	area := jsDoc.Call("getElementById", "ToastArea")
+	if !area.Truthy() {
+		log.Debug("No ToastArea for notification: ", n.Title)
+		return
+	}
+	toast := notificationElement(n, "Toast_Class")
+	var once sync.Once
+	var dismiss js.Func
+	closeToast := func() {
+		once.Do(func() {
+			toast.Call("removeEventListener", "click", dismiss)
+			toast.Call("remove")
+			dismiss.Release()
+		})
+	}
+	dismiss = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		closeToast()
+		return nil
+	})
+	toast.Call("addEventListener", "click", dismiss)
+	area.Call("appendChild", toast)
+	timeout := ToastTimeout
+	if n.Level == LevelError {
+		timeout *= 2
+	}
+	time.AfterFunc(timeout, closeToast)
+}
+
+func renderJSNotifications() {
+	jsDoc := js.Global().Get("document")
+	if !jsDoc.Truthy() {
+		return
+	}
+	notifyMtx.Lock()
+	defer notifyMtx.Unlock()
+	count := jsDoc.Call("getElementById", "NotificationCount")
+	if count.Truthy() {
+		if unreadCount > 0 {
+			count.Set("textContent", fmt.Sprintf("%d", unreadCount))
+			count.Get("style").Set("display", "inline-block")
+		} else {
+			count.Get("style").Set("display", "none")
+		}
+	}
+	list := jsDoc.Call("getElementById", "NotificationList")
+	if !list.Truthy() {
+		return
+	}
+	list.Set("innerHTML", "")
+	if len(notifications) == 0 {
+		empty := jsDoc.Call("createElement", "div")
+		empty.Set("className", "NotificationEmpty_Class")
+		empty.Set("textContent", "No notifications")
+		list.Call("appendChild", empty)
+		return
+	}
+	for i := len(notifications) - 1; i >= 0; i-- {
+		n := notifications[i]
+		item := notificationElement(n, "NotificationItem_Class")
+		timestamp := jsDoc.Call("createElement", "div")
+		timestamp.Set("className", "NotificationTime_Class")
+		timestamp.Set("textContent", js.Global().Get("Date").New(n.Time).Call("toLocaleString").String())
+		item.Call("appendChild", timestamp)
+		list.Call("appendChild", item)
+	}
+}
+
+// ToggleNotifications opens or closes the notification history panel
+func ToggleNotifications() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		panel := js.Global().Get("document").Call("getElementById", "NotificationPanel")
+		if !panel.Truthy() {
+			return nil
+		}
+		open := panel.Get("style").Get("display").String() == "block"
+		if open {
+			panel.Get("style").Set("display", "none")
+			return nil
+		}
+		notifyMtx.Lock()
+		unreadCount = 0
+		notifyMtx.Unlock()
+		renderNotifications()
+		panel.Get("style").Set("display", "block")
+		return nil
+	})
+}
+
+// ClearNotifications empties the notification history
+func ClearNotifications() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		notifyMtx.Lock()
+		notifications = nil
+		unreadCount = 0
+		notifyMtx.Unlock()
+		saveNotifications()
+		renderNotifications()
+		return nil
+	})
+}
+
+// GetNotifications returns the notification history, oldest first
+func GetNotifications() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		notifyMtx.Lock()
+		buf, err := json.Marshal(notifications)
+		notifyMtx.Unlock()
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return js.Global().Get("JSON").Call("parse", string(buf))
+	})
+}
+
+// LoadLogLevel sets the log level from the "logLevel" query parameter or
+// window.hiveConfig.logLevel, keeping the console to errors by default
+func LoadLogLevel() {
+	level := "Error"
+	if value, err := windowConfig("logLevel"); err == nil && value != "" {
+		level = value
+	}
+	location := js.Global().Get("location")
+	if location.Truthy() {
+		param := js.Global().Get("URLSearchParams").New(location.Get("search")).Call("get", "logLevel")
+		if param.Type() == js.TypeString {
+			level = param.String()
+		}
+	}
+	err := logger.SetLogLevel("*", level)
+	if err != nil {
+		logger.SetLogLevel("*", "Error")
+		log.Error("Invalid log level: ", level)
+	}
+}
+
+// SetLogLevel changes the console log level, e.g. SetLogLevel("debug")
+func SetLogLevel() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 1 || args[0].Type() != js.TypeString {
+			return js.Global().Get("Error").New("SetLogLevel expects a level")
+		}
+		err := logger.SetLogLevel("*", args[0].String())
+		if err != nil {
+			return js.Global().Get("Error").New(err.Error())
+		}
+		return nil
+	})
+}
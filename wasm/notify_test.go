@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestLoadNotifications(t *testing.T) {
+	fake := useFakeDOM()
+	resetNotifications(t)
+	var history []*Notification
+	for i := 0; i < NotificationHistorySize; i++ {
+		history = append(history, &Notification{ID: int64(i), Level: LevelInfo, Title: fmt.Sprintf("saved %d", i), Count: 1})
+	}
+	buf, err := json.Marshal(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// raised before the history was loaded
+	Notify(LevelError, "Error", "daemon is not running", "")
+	Notify(LevelWarning, "Event stream closed", "reconnecting", "")
+	fake.SetStorageItem(NotificationStorageKey, string(buf))
+
+	LoadNotifications()
+	if len(notifications) != NotificationHistorySize {
+		t.Fatalf("got %d notifications, want %d", len(notifications), NotificationHistorySize)
+	}
+	// the oldest saved ones make room for the new ones
+	if got := notifications[0].Title; got != "saved 2" {
+		t.Errorf("oldest: got %q", got)
+	}
+	if got := notifications[NotificationHistorySize-1].Title; got != "Event stream closed" {
+		t.Errorf("latest: got %q", got)
+	}
+
+	fake.SetStorageItem(NotificationStorageKey, "not json")
+	LoadNotifications()
+	if _, found := fake.StorageItem(NotificationStorageKey); found {
+		t.Error("a corrupt history was kept")
+	}
+}
@@ -0,0 +1,233 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+const (
+	PingAction       = "ping"
+	ConnectAction    = "connect"
+	DisconnectAction = "disconnect"
+
+	// PeerActionTimeout bounds how long a peer action waits for the daemon
+	PeerActionTimeout = 30 * time.Second
+)
+
+// PeerResult is the outcome of the latest action on a peer, shown in its row
+// of the peer table. Latency is set for a successful ping.
+type PeerResult struct {
+	Action  string        `json:"action"`
+	OK      bool          `json:"ok"`
+	Pending bool          `json:"pending,omitempty"`
+	Text    string        `json:"text"`
+	Latency time.Duration `json:"latency,omitempty"`
+}
+
+// className is the class of the result in the peer table
+func (r PeerResult) className() string {
+	switch {
+	case r.Pending:
+		return "PeerResult_Class pending"
+	case r.OK:
+		return "PeerResult_Class ok"
+	}
+	return "PeerResult_Class failed"
+}
+
+var (
+	peerResultsMtx sync.Mutex
+	peerResults    = make(map[string]PeerResult)
+)
+
+// SetPeerResult shows result in the row of a peer
+func SetPeerResult(peerID string, result PeerResult) {
+	peerResultsMtx.Lock()
+	peerResults[peerID] = result
+	peerResultsMtx.Unlock()
+	RenderPeers()
+}
+
+func peerResult(peerID string) (PeerResult, bool) {
+	peerResultsMtx.Lock()
+	defer peerResultsMtx.Unlock()
+	result, found := peerResults[peerID]
+	return result, found
+}
+
+func clearPeerResult(peerID string) {
+	peerResultsMtx.Lock()
+	delete(peerResults, peerID)
+	peerResultsMtx.Unlock()
+}
+
+// FormatLatency renders a round trip in milliseconds
+func FormatLatency(latency time.Duration) string {
+	return fmt.Sprintf("%.1f ms", float64(latency)/float64(time.Millisecond))
+}
+
+// errorText is the reason of a failed command, without the command itself
+func errorText(err error) string {
+	if e, ok := err.(*client.Error); ok && e.Message != "" {
+		return e.Message
+	}
+	return err.Error()
+}
+
+// PingResult turns the answer of a ping into a result. The latency is the
+// round trip reported by the daemon, or elapsed when it reports none.
+func PingResult(resp *client.PingResponse, err error, elapsed time.Duration) PeerResult {
+	result := PeerResult{Action: PingAction}
+	switch {
+	case err != nil:
+		result.Text = "ping failed: " + errorText(err)
+	case !resp.Success:
+		result.Text = "unreachable"
+		if resp.Text != "" {
+			result.Text += ": " + resp.Text
+		}
+	default:
+		result.OK = true
+		result.Latency = resp.Time
+		if result.Latency == 0 {
+			result.Latency = elapsed
+		}
+		result.Text = FormatLatency(result.Latency)
+	}
+	return result
+}
+
+// CommandResult turns the answer of swarm connect or disconnect into a
+// result
+func CommandResult(action string, err error) PeerResult {
+	if err != nil {
+		return PeerResult{Action: action, Text: action + " failed: " + errorText(err)}
+	}
+	text := "connected"
+	if action == DisconnectAction {
+		text = "disconnected"
+	}
+	return PeerResult{Action: action, OK: true, Text: text}
+}
+
+// DialAddress parses an address typed in the dial form, it has to name the
+// peer to connect to
+func DialAddress(raw string) (client.Multiaddr, error) {
+	addr, err := client.ParseMultiaddr(strings.TrimSpace(raw))
+	if err != nil {
+		return addr, err
+	}
+	if addr.PeerID == "" {
+		return addr, fmt.Errorf("multiaddr %q doesn't end with /p2p/<peer ID>", addr.Raw)
+	}
+	return addr, nil
+}
+
+// RunPeerAction starts an action clicked in the peer table, value is the
+// peer ID to ping or disconnect, or the address to connect to
+func RunPeerAction(action, value string) error {
+	var run func(string) PeerResult
+	switch action {
+	case PingAction:
+		run = PingPeer
+	case DisconnectAction:
+		run = DisconnectPeer
+	case ConnectAction:
+		run = ConnectAddress
+	default:
+		return fmt.Errorf("unknown peer action %q", action)
+	}
+	if value == "" {
+		return fmt.Errorf("missing peer for %s", action)
+	}
+	go run(value)
+	return nil
+}
+
+// RenderPeerStatus shows the latest finished action under the peer filter,
+// it stays after a disconnected peer leaves the table
+func RenderPeerStatus(peerID string, result PeerResult) {
+	SetMultipleDisplay("PeerActionStatus", map[string]string{
+		"textContent": fmt.Sprintf("%s %s: %s", result.Action, ShortPeerID(peerID), result.Text),
+		"className":   strings.Replace(result.className(), "PeerResult_Class", "PeerActionStatus_Class", 1),
+		"title":       peerID,
+	})
+}
+
+// PingPeer pings a connected peer through the daemon
+func PingPeer(peerID string) PeerResult {
+	SetPeerResult(peerID, PeerResult{Action: PingAction, Pending: true, Text: "pinging…"})
+	ctx, cancel := context.WithTimeout(context.Background(), PeerActionTimeout)
+	defer cancel()
+	start := time.Now()
+	resp, err := Hive().Ping(ctx, peerID)
+	result := PingResult(resp, err, time.Since(start))
+	SetPeerResult(peerID, result)
+	RenderPeerStatus(peerID, result)
+	return result
+}
+
+// DisconnectPeer closes every connection to a peer
+func DisconnectPeer(peerID string) PeerResult {
+	peers, _ := state.Peers()
+	addrs, _ := client.ParseMultiaddrs(peers)
+	var raw []string
+	for _, addr := range addrs {
+		if addr.PeerID == peerID {
+			raw = append(raw, addr.Raw)
+		}
+	}
+	if len(raw) == 0 {
+		result := PeerResult{Action: DisconnectAction, Text: "disconnect failed: not connected"}
+		RenderPeerStatus(peerID, result)
+		return result
+	}
+	SetPeerResult(peerID, PeerResult{Action: DisconnectAction, Pending: true, Text: "disconnecting…"})
+	ctx, cancel := context.WithTimeout(context.Background(), PeerActionTimeout)
+	defer cancel()
+	var err error
+	for _, addr := range raw {
+		_, err = Hive().SwarmDisconnect(ctx, addr)
+		if err != nil {
+			break
+		}
+	}
+	result := CommandResult(DisconnectAction, err)
+	if result.OK {
+		clearPeerResult(peerID)
+	} else {
+		SetPeerResult(peerID, result)
+	}
+	RenderPeerStatus(peerID, result)
+	GetPeers()
+	return result
+}
+
+// ConnectAddress dials a multiaddr and refreshes the peers once connected
+func ConnectAddress(raw string) PeerResult {
+	addr, err := DialAddress(raw)
+	if err != nil {
+		result := PeerResult{Action: ConnectAction, Text: "connect failed: " + err.Error()}
+		SetMultipleDisplay("PeerActionStatus", map[string]string{
+			"textContent": result.Text,
+			"className":   "PeerActionStatus_Class failed",
+			"title":       raw,
+		})
+		return result
+	}
+	SetPeerResult(addr.PeerID, PeerResult{Action: ConnectAction, Pending: true, Text: "connecting…"})
+	ctx, cancel := context.WithTimeout(context.Background(), PeerActionTimeout)
+	defer cancel()
+	_, err = Hive().SwarmConnect(ctx, addr.Raw)
+	result := CommandResult(ConnectAction, err)
+	SetPeerResult(addr.PeerID, result)
+	RenderPeerStatus(addr.PeerID, result)
+	GetPeers()
+	return result
+}
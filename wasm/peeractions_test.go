@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func resetPeerResults(t *testing.T) {
+	peerResults = make(map[string]PeerResult)
+	t.Cleanup(func() { peerResults = make(map[string]PeerResult) })
+}
+
+func TestPingResult(t *testing.T) {
+	failed := &client.Error{Command: "ping QmA -j", Status: 500, Message: "daemon is not running"}
+	for _, tc := range []struct {
+		resp *client.PingResponse
+		err  error
+		want PeerResult
+	}{
+		{&client.PingResponse{Success: true, Time: 23500 * time.Microsecond}, nil,
+			PeerResult{Action: PingAction, OK: true, Text: "23.5 ms", Latency: 23500 * time.Microsecond}},
+		// without a time from the daemon the round trip through the gateway is shown
+		{&client.PingResponse{Success: true}, nil,
+			PeerResult{Action: PingAction, OK: true, Text: "120.0 ms", Latency: 120 * time.Millisecond}},
+		{&client.PingResponse{Text: "peer QmA is not connected"}, nil,
+			PeerResult{Action: PingAction, Text: "unreachable: peer QmA is not connected"}},
+		{&client.PingResponse{}, nil, PeerResult{Action: PingAction, Text: "unreachable"}},
+		{nil, failed, PeerResult{Action: PingAction, Text: "ping failed: daemon is not running"}},
+		{nil, errors.New("context deadline exceeded"), PeerResult{Action: PingAction, Text: "ping failed: context deadline exceeded"}},
+	} {
+		if got := PingResult(tc.resp, tc.err, 120*time.Millisecond); got != tc.want {
+			t.Errorf("%+v, %v: got %+v, want %+v", tc.resp, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestCommandResult(t *testing.T) {
+	if got, want := CommandResult(ConnectAction, nil), (PeerResult{Action: ConnectAction, OK: true, Text: "connected"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got, want := CommandResult(DisconnectAction, nil), (PeerResult{Action: DisconnectAction, OK: true, Text: "disconnected"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	failed := &client.Error{Status: 400, Message: "not connected", Details: "/p2p/QmA"}
+	if got, want := CommandResult(DisconnectAction, failed), (PeerResult{Action: DisconnectAction, Text: "disconnect failed: not connected"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDialAddress(t *testing.T) {
+	addr, err := DialAddress("  /ip4/198.51.100.7/udp/4001/quic/p2p/QmPeerA ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.PeerID != "QmPeerA" || addr.Raw != "/ip4/198.51.100.7/udp/4001/quic/p2p/QmPeerA" {
+		t.Errorf("got %+v", addr)
+	}
+	for _, raw := range []string{"", "198.51.100.7:4001", "/ip4/198.51.100.7/tcp/4001"} {
+		if _, err := DialAddress(raw); err == nil {
+			t.Errorf("%q: dialed", raw)
+		}
+	}
+}
+
+func TestRunPeerAction(t *testing.T) {
+	if err := RunPeerAction("traceroute", "QmPeerA"); err == nil {
+		t.Error("ran an unknown action")
+	}
+	if err := RunPeerAction(PingAction, ""); err == nil {
+		t.Error("pinged without a peer")
+	}
+}
+
+func TestTrackPeersDropsResults(t *testing.T) {
+	resetPeerResults(t)
+	resetPeerTracker(t)
+	useFakeDOM("PeerChurn", "PeerTimeline")
+	now := time.Now()
+	TrackPeers([]string{peerAddr("QmA"), peerAddr("QmB")}, now.Add(-time.Minute))
+	peerResults["QmA"] = PeerResult{Action: PingAction, OK: true, Text: "12.0 ms"}
+	peerResults["QmB"] = PeerResult{Action: DisconnectAction, Text: "disconnect failed: timeout"}
+	TrackPeers([]string{peerAddr("QmB")}, now)
+	if _, found := peerResult("QmA"); found {
+		t.Error("kept the result of a peer that left")
+	}
+	if _, found := peerResult("QmB"); !found {
+		t.Error("dropped the result of a connected peer")
+	}
+}
+
+func TestRenderPeerActions(t *testing.T) {
+	resetPeerOrder(t)
+	resetPeerTracker(t)
+	resetPeerResults(t)
+	fake := useFakeDOM("PeerFilter", "PeerSummary", "PeerTable", "PeerActionStatus")
+	state.SetPeers(testPeers)
+	SetPeerResult("QmPeerA", PeerResult{Action: PingAction, Pending: true, Text: "pinging…"})
+
+	// QmPeerA is the first peer
+	if got := fake.Children("PeerGroup0-actions", "name"); !reflect.DeepEqual(got, []string{PingAction, DisconnectAction, ""}) {
+		t.Errorf("actions: got %q", got)
+	}
+	if got := fake.Children("PeerGroup0-actions", "disabled"); !reflect.DeepEqual(got, []string{"true", "true", ""}) {
+		t.Errorf("pending actions: got %q", got)
+	}
+	// addresses are connected already, they have no actions
+	if got := fake.Children("PeerGroup0-0", "textContent"); len(got) != 9 || got[8] != "" {
+		t.Errorf("address actions: got %q", got)
+	}
+	if _, found := fake.Property("PeerGroup0-0-actions", "id"); found {
+		t.Error("the address row has an action cell")
+	}
+
+	result := PeerResult{Action: PingAction, OK: true, Text: "23.5 ms", Latency: 23500 * time.Microsecond}
+	SetPeerResult("QmPeerA", result)
+	RenderPeerStatus("QmPeerA", result)
+	if got := fake.Children("PeerGroup0-actions", "textContent"); !reflect.DeepEqual(got, []string{"Ping", "Disconnect", "23.5 ms"}) {
+		t.Errorf("result: got %q", got)
+	}
+	if got := fake.Children("PeerGroup0-actions", "className"); got[2] != "PeerResult_Class ok" {
+		t.Errorf("result class: got %q", got[2])
+	}
+	if got := fake.Children("PeerGroup0-actions", "disabled"); !reflect.DeepEqual(got, []string{"", "", ""}) {
+		t.Errorf("finished actions: got %q", got)
+	}
+	if got, _ := fake.Property("PeerActionStatus", "textContent"); got != "ping QmPeerA: 23.5 ms" {
+		t.Errorf("PeerActionStatus: got %q", got)
+	}
+	// peers without a result only have their buttons
+	if got := fake.Children("PeerGroup1-actions", "textContent"); !reflect.DeepEqual(got, []string{"Ping", "Disconnect"}) {
+		t.Errorf("QmPeerB actions: got %q", got)
+	}
+
+	if got := ConnectAddress("/ip4/198.51.100.7/tcp/4001"); got.OK {
+		t.Errorf("connected without a peer ID: %+v", got)
+	}
+	if got, _ := fake.Property("PeerActionStatus", "className"); got != "PeerActionStatus_Class failed" {
+		t.Errorf("PeerActionStatus class: got %q", got)
+	}
+}
@@ -0,0 +1,334 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+// PeerColumn is a sortable column of the peer table
+type PeerColumn struct {
+	Key   string
+	Id    string
+	Label string
+}
+
+// PeerColumns are the columns of the peer table, in order
+var PeerColumns = []PeerColumn{
+	{"peer", "PeerSortPeer", "PEER"},
+	{"connections", "PeerSortConnections", "CONN"},
+	{"transport", "PeerSortTransport", "TRANSPORT"},
+	{"ip", "PeerSortIP", "IP"},
+	{"address", "PeerSortAddress", "ADDRESS"},
+	{"port", "PeerSortPort", "PORT"},
+	{"relay", "PeerSortRelay", "RELAY"},
+	{"session", "PeerSortSession", "SESSION"},
+}
+
+// PeerOrder is how the peer table is sorted
+type PeerOrder struct {
+	Column     string
+	Descending bool
+}
+
+var (
+	peerOrderMtx sync.Mutex
+	peerOrder    = PeerOrder{Column: "peer"}
+)
+
+// SortPeerTable sorts the peer table by column, sorting it again by the
+// same column reverses the order
+func SortPeerTable(column string) error {
+	found := false
+	for _, c := range PeerColumns {
+		found = found || c.Key == column
+	}
+	if !found {
+		return fmt.Errorf("unknown peer column %q", column)
+	}
+	peerOrderMtx.Lock()
+	if peerOrder.Column == column {
+		peerOrder.Descending = !peerOrder.Descending
+	} else {
+		peerOrder = PeerOrder{Column: column}
+	}
+	peerOrderMtx.Unlock()
+	RenderPeers()
+	return nil
+}
+
+func currentPeerOrder() PeerOrder {
+	peerOrderMtx.Lock()
+	defer peerOrderMtx.Unlock()
+	return peerOrder
+}
+
+// FilterPeerGroups keeps the addresses containing filter, ignoring case.
+// Peers without any address left are dropped.
+func FilterPeerGroups(groups []client.PeerGroup, filter string) []client.PeerGroup {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return groups
+	}
+	var filtered []client.PeerGroup
+	for _, group := range groups {
+		var addrs []client.Multiaddr
+		for _, addr := range group.Addrs {
+			if strings.Contains(strings.ToLower(addr.Raw), filter) {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) > 0 {
+			filtered = append(filtered, client.GroupPeers(addrs)...)
+		}
+	}
+	return filtered
+}
+
+// compareAddrs orders two addresses by a column
+func compareAddrs(a, b client.Multiaddr, column string) int {
+	switch column {
+	case "transport":
+		return strings.Compare(a.Transport, b.Transport)
+	case "ip":
+		return a.IPVersion - b.IPVersion
+	case "address":
+		return strings.Compare(a.Address, b.Address)
+	case "port":
+		return a.Port - b.Port
+	case "relay":
+		return strings.Compare(a.RelayID, b.RelayID)
+	}
+	return 0
+}
+
+// SortPeerGroups sorts the peers and the addresses of every peer in order.
+// Peers are compared by their first address for the address columns, by
+// how long they have been connected for the session, and by peer ID when
+// they are equal.
+func SortPeerGroups(groups []client.PeerGroup, order PeerOrder, sessions func(peerID string) (PeerSession, bool)) {
+	sign := 1
+	if order.Descending {
+		sign = -1
+	}
+	for _, group := range groups {
+		addrs := group.Addrs
+		sort.SliceStable(addrs, func(i, j int) bool {
+			return sign*compareAddrs(addrs[i], addrs[j], order.Column) < 0
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		var cmp int
+		switch order.Column {
+		case "peer":
+		case "connections":
+			cmp = len(a.Addrs) - len(b.Addrs)
+		case "relay":
+			cmp = boolOrder(a.Relayed) - boolOrder(b.Relayed)
+			if cmp == 0 {
+				cmp = compareAddrs(a.Addrs[0], b.Addrs[0], order.Column)
+			}
+		case "session":
+			cmp = compareSessions(sessions, a.PeerID, b.PeerID)
+		default:
+			cmp = compareAddrs(a.Addrs[0], b.Addrs[0], order.Column)
+		}
+		if cmp == 0 {
+			cmp = strings.Compare(a.PeerID, b.PeerID)
+		}
+		return sign*cmp < 0
+	})
+}
+
+// compareSessions orders peers from the shortest session to the longest,
+// peers without a session first
+func compareSessions(sessions func(peerID string) (PeerSession, bool), a, b string) int {
+	sessionA, foundA := sessions(a)
+	sessionB, foundB := sessions(b)
+	switch {
+	case !foundA || !foundB:
+		return boolOrder(foundA) - boolOrder(foundB)
+	case sessionA.Since.After(sessionB.Since):
+		return -1
+	case sessionA.Since.Before(sessionB.Since):
+		return 1
+	}
+	return 0
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// IPVersionLabel names the IP version of an address
+func IPVersionLabel(addr client.Multiaddr) string {
+	switch addr.IPVersion {
+	case 4:
+		return "IPv4"
+	case 6:
+		return "IPv6"
+	}
+	if addr.Address != "" {
+		return "DNS"
+	}
+	return ""
+}
+
+// ShortPeerID shortens a peer ID to its first and last characters
+func ShortPeerID(id string) string {
+	if len(id) <= 16 {
+		return id
+	}
+	return id[:8] + "…" + id[len(id)-6:]
+}
+
+// PeerSummary counts the peers, their connections per transport and the
+// addresses that could not be parsed
+func PeerSummary(groups []client.PeerGroup, shown, unparsed int) string {
+	connections := 0
+	transports := make(map[string]int)
+	for _, group := range groups {
+		connections += len(group.Addrs)
+		for transport, count := range group.Transports {
+			transports[transport] += count
+		}
+	}
+	summary := fmt.Sprintf("%d peers, %d connections", len(groups), connections)
+	if len(transports) > 0 {
+		summary += ": " + client.TransportCounts(transports)
+	}
+	if shown != len(groups) {
+		summary += fmt.Sprintf(" (%d shown)", shown)
+	}
+	if unparsed > 0 {
+		summary += fmt.Sprintf(" (%d unparsed)", unparsed)
+	}
+	return summary
+}
+
+// RenderPeers renders the connected peers grouped by peer ID, filtered and
+// sorted as selected on the page
+func RenderPeers() {
+	peers, _ := state.Peers()
+	addrs, errs := client.ParseMultiaddrs(peers)
+	for _, err := range errs {
+		log.Warn("Skipping peer: ", err.Error())
+	}
+	groups := client.GroupPeers(addrs)
+	filter, _ := dom.Property("PeerFilter", "value")
+	shown := FilterPeerGroups(groups, filter)
+	order := currentPeerOrder()
+	SortPeerGroups(shown, order, peerTracker.Session)
+	now := time.Now()
+
+	SetText("PeerSummary", PeerSummary(groups, len(shown), len(errs)))
+	for _, column := range PeerColumns {
+		label := column.Label
+		if column.Key == order.Column && order.Descending {
+			label += " ▼"
+		} else if column.Key == order.Column {
+			label += " ▲"
+		}
+		SetText(column.Id, label)
+	}
+	SetDisplay("PeerTable", "innerHTML", "")
+	for i, group := range shown {
+		row := fmt.Sprintf("PeerGroup%d", i)
+		relayed := ""
+		if group.Relayed {
+			relayed = "RELAYED"
+		}
+		session := ""
+		if s, found := peerTracker.Session(group.PeerID); found {
+			session = FormatSession(s, now)
+		}
+		appendRow("PeerTable", row, "PeerGroupRow_Class", []map[string]string{
+			{"textContent": ShortPeerID(group.PeerID), "title": group.PeerID},
+			{"textContent": fmt.Sprintf("%d", len(group.Addrs))},
+			{"textContent": client.TransportCounts(group.Transports)},
+			{}, {}, {},
+			{"textContent": relayed},
+			{"textContent": session},
+			{"id": row + "-actions"},
+		})
+		result, found := peerResult(group.PeerID)
+		appendAction(row+"-actions", PingAction, group.PeerID, "Ping", result.Pending)
+		appendAction(row+"-actions", DisconnectAction, group.PeerID, "Disconnect", result.Pending)
+		if found {
+			dom.AppendChild(row+"-actions", "span", map[string]string{
+				"className":   result.className(),
+				"textContent": result.Text,
+			})
+		}
+		for j, addr := range group.Addrs {
+			relay := ""
+			if addr.Relay {
+				relay = ShortPeerID(addr.RelayID)
+			}
+			port := ""
+			if addr.Port != 0 {
+				port = fmt.Sprintf("%d", addr.Port)
+			}
+			appendRow("PeerTable", fmt.Sprintf("%s-%d", row, j), "PeerAddrRow_Class", []map[string]string{
+				{"title": addr.Raw}, {},
+				{"textContent": addr.Transport},
+				{"textContent": IPVersionLabel(addr)},
+				{"textContent": addr.Address, "title": addr.Raw},
+				{"textContent": port},
+				{"textContent": relay, "title": addr.RelayID},
+				{}, {},
+			})
+		}
+	}
+}
+
+// appendRow appends a table row with id and a cell for every set of
+// properties
+func appendRow(table, id, className string, cells []map[string]string) {
+	if !dom.AppendChild(table, "tr", map[string]string{"id": id, "className": className}) {
+		log.Debugf("No element %s on this page", table)
+		return
+	}
+	for _, cell := range cells {
+		dom.AppendChild(id, "td", cell)
+	}
+}
+
+// appendAction appends a button running a peer action on value, the peer
+// table hands its clicks to RunPeerAction by the button name and value
+func appendAction(cell, action, value, label string, disabled bool) {
+	properties := map[string]string{
+		"className":   "PeerAction_Class",
+		"name":        action,
+		"value":       value,
+		"textContent": label,
+	}
+	if disabled {
+		properties["disabled"] = "true"
+	}
+	dom.AppendChild(cell, "button", properties)
+}
+
+// RenderAddresses renders the addresses the node listens on
+func RenderAddresses(addresses []string) {
+	SetDisplay("Address", "innerHTML", "")
+	for _, value := range addresses {
+		addr, err := client.ParseMultiaddr(value)
+		text := value
+		if err != nil {
+			log.Warn("Showing an unparsed address: ", err.Error())
+		} else {
+			text = fmt.Sprintf("%s %s %s", IPVersionLabel(addr), addr.Transport, addr.HostPort())
+		}
+		dom.AppendChild("Address", "div", map[string]string{"textContent": text, "title": value})
+	}
+}
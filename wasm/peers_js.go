@@ -0,0 +1,56 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"syscall/js"
+)
+
+// SortPeers sorts the peer table by a column, "peer", "connections",
+// "transport", "ip", "address", "port", "relay" or "session"
+func SortPeers() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			log.Error("Error in SortPeers: ", "missing column")
+			return nil
+		}
+		err := SortPeerTable(args[0].String())
+		if err != nil {
+			log.Error("Error in SortPeers: ", err.Error())
+		}
+		return nil
+	})
+}
+
+// FilterPeers renders the peers matching the filter typed on the page
+func FilterPeers() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		RenderPeers()
+		return nil
+	})
+}
+
+// PeerAction runs the action of a button clicked in the peer table, by the
+// name and value of the button. Clicks outside a button are ignored.
+func PeerAction() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 2 || args[0].Type() != js.TypeString || args[0].String() == "" {
+			return nil
+		}
+		err := RunPeerAction(args[0].String(), args[1].String())
+		if err != nil {
+			log.Error("Error in PeerAction: ", err.Error())
+		}
+		return nil
+	})
+}
+
+// DialPeer connects to the multiaddr typed in the dial form
+func DialPeer() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go ConnectAddress(GetValue("PeerDialAddr", "value"))
+		return nil
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+var testPeers = []string{
+	"/ip4/198.51.100.7/tcp/4001/p2p/QmPeerB",
+	"/ip4/198.51.100.8/udp/4002/quic/p2p/QmPeerA",
+	"/ip6/2001:db8::7/tcp/4003/ws/p2p/QmPeerB",
+	"/ip4/198.51.100.9/tcp/4004/p2p/QmRelay/p2p-circuit/p2p/QmPeerC",
+	"/ip4/198.51.100.10/tcp/4005/p2p/QmPeerB",
+	"/unix/tmp/hive.sock",
+}
+
+func resetPeerOrder(t *testing.T) {
+	peerOrder = PeerOrder{Column: "peer"}
+	t.Cleanup(func() { peerOrder = PeerOrder{Column: "peer"} })
+}
+
+func resetPeerTracker(t *testing.T) {
+	peerTracker = NewPeerTracker()
+	t.Cleanup(func() { peerTracker = NewPeerTracker() })
+}
+
+func peerIDs(groups []client.PeerGroup) []string {
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.PeerID)
+	}
+	return ids
+}
+
+func TestSortPeerGroups(t *testing.T) {
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	sessions := map[string]PeerSession{
+		"QmPeerA": {Since: start, Known: true},
+		"QmPeerB": {Since: start.Add(5 * time.Minute)},
+	}
+	lookup := func(peerID string) (PeerSession, bool) {
+		session, found := sessions[peerID]
+		return session, found
+	}
+	for _, tc := range []struct {
+		order PeerOrder
+		peers []string
+		ports []int
+	}{
+		{PeerOrder{Column: "peer"}, []string{"QmPeerA", "QmPeerB", "QmPeerC"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "peer", Descending: true}, []string{"QmPeerC", "QmPeerB", "QmPeerA"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "connections", Descending: true}, []string{"QmPeerB", "QmPeerC", "QmPeerA"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "port"}, []string{"QmPeerB", "QmPeerA", "QmPeerC"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "port", Descending: true}, []string{"QmPeerB", "QmPeerC", "QmPeerA"}, []int{4005, 4003, 4001}},
+		{PeerOrder{Column: "transport"}, []string{"QmPeerA", "QmPeerB", "QmPeerC"}, []int{4001, 4005, 4003}},
+		{PeerOrder{Column: "ip", Descending: true}, []string{"QmPeerB", "QmPeerC", "QmPeerA"}, []int{4003, 4001, 4005}},
+		{PeerOrder{Column: "address"}, []string{"QmPeerB", "QmPeerA", "QmPeerC"}, []int{4005, 4001, 4003}},
+		{PeerOrder{Column: "relay", Descending: true}, []string{"QmPeerC", "QmPeerB", "QmPeerA"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "session"}, []string{"QmPeerC", "QmPeerB", "QmPeerA"}, []int{4001, 4003, 4005}},
+		{PeerOrder{Column: "session", Descending: true}, []string{"QmPeerA", "QmPeerB", "QmPeerC"}, []int{4001, 4003, 4005}},
+	} {
+		addrs, _ := client.ParseMultiaddrs(testPeers)
+		groups := client.GroupPeers(addrs)
+		SortPeerGroups(groups, tc.order, lookup)
+		var ports []int
+		for _, group := range groups {
+			if group.PeerID != "QmPeerB" {
+				continue
+			}
+			for _, addr := range group.Addrs {
+				ports = append(ports, addr.Port)
+			}
+		}
+		if got := peerIDs(groups); !reflect.DeepEqual(got, tc.peers) || !reflect.DeepEqual(ports, tc.ports) {
+			t.Errorf("%+v: got %v with ports %v, want %v with %v", tc.order, got, ports, tc.peers, tc.ports)
+		}
+	}
+}
+
+func TestFilterPeerGroups(t *testing.T) {
+	addrs, _ := client.ParseMultiaddrs(testPeers)
+	groups := client.GroupPeers(addrs)
+	for _, tc := range []struct {
+		filter string
+		peers  []string
+		addrs  int
+	}{
+		{"", []string{"QmPeerB", "QmPeerA", "QmPeerC"}, 5},
+		{"  ", []string{"QmPeerB", "QmPeerA", "QmPeerC"}, 5},
+		{"QUIC", []string{"QmPeerA"}, 1},
+		{"qmpeerb", []string{"QmPeerB"}, 3},
+		{"/tcp/", []string{"QmPeerB", "QmPeerC"}, 4},
+		{"qmrelay", []string{"QmPeerC"}, 1},
+		{"sctp", []string{}, 0},
+	} {
+		filtered := FilterPeerGroups(groups, tc.filter)
+		count := 0
+		for _, group := range filtered {
+			count += len(group.Addrs)
+		}
+		if got := peerIDs(filtered); !reflect.DeepEqual(got, tc.peers) || count != tc.addrs {
+			t.Errorf("%q: got %v with %d addresses, want %v with %d", tc.filter, got, count, tc.peers, tc.addrs)
+		}
+	}
+}
+
+func TestRenderPeers(t *testing.T) {
+	resetPeerOrder(t)
+	resetPeerTracker(t)
+	peerTracker.Update(testPeers[:2], time.Now().Add(-90*time.Second))
+	ids := []string{"PeerFilter", "PeerSummary", "PeerTable"}
+	for _, column := range PeerColumns {
+		ids = append(ids, column.Id)
+	}
+	fake := useFakeDOM(ids...)
+	state.SetPeers(testPeers)
+	RenderPeers()
+
+	if got, _ := fake.Property("PeerSummary", "textContent"); got != "3 peers, 5 connections: quic 1, tcp 3, ws 1 (1 unparsed)" {
+		t.Errorf("PeerSummary: got %q", got)
+	}
+	if got, _ := fake.Property("PeerSortPeer", "textContent"); got != "PEER ▲" {
+		t.Errorf("PeerSortPeer: got %q", got)
+	}
+	if got := fake.Children("PeerTable", "id"); !reflect.DeepEqual(got, []string{
+		"PeerGroup0", "PeerGroup0-0",
+		"PeerGroup1", "PeerGroup1-0", "PeerGroup1-1", "PeerGroup1-2",
+		"PeerGroup2", "PeerGroup2-0",
+	}) {
+		t.Errorf("rows: got %v", got)
+	}
+	for _, tc := range []struct {
+		row  string
+		want []string
+	}{
+		{"PeerGroup1-1", []string{"", "", "ws", "IPv6", "2001:db8::7", "4003", "", "", ""}},
+		{"PeerGroup2", []string{"QmPeerC", "1", "tcp 1", "", "", "", "RELAYED", "", ""}},
+		{"PeerGroup2-0", []string{"", "", "tcp", "IPv4", "198.51.100.9", "4004", "QmRelay", "", ""}},
+	} {
+		if got := fake.Children(tc.row, "textContent"); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.row, got, tc.want)
+		}
+	}
+	// QmPeerB was connected before tracking started
+	group := fake.Children("PeerGroup1", "textContent")
+	if want := []string{"QmPeerB", "3", "tcp 2, ws 1", "", "", "", ""}; len(group) != 9 || !reflect.DeepEqual(group[:7], want) || !strings.HasPrefix(group[7], "≥ 1 minute") {
+		t.Errorf("PeerGroup1: got %q", group)
+	}
+
+	fake.SetProperty("PeerFilter", "value", "4001")
+	if err := SortPeerTable("port"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SortPeerTable("port"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fake.Property("PeerSummary", "textContent"); got != "3 peers, 5 connections: quic 1, tcp 3, ws 1 (1 shown) (1 unparsed)" {
+		t.Errorf("filtered PeerSummary: got %q", got)
+	}
+	if got, _ := fake.Property("PeerSortPort", "textContent"); got != "PORT ▼" {
+		t.Errorf("PeerSortPort: got %q", got)
+	}
+	if got, _ := fake.Property("PeerSortPeer", "textContent"); got != "PEER" {
+		t.Errorf("PeerSortPeer: got %q", got)
+	}
+	if got := fake.Children("PeerTable", "id"); !reflect.DeepEqual(got, []string{"PeerGroup0", "PeerGroup0-0"}) {
+		t.Errorf("filtered rows: got %v", got)
+	}
+	// rows of the previous render are gone
+	if _, found := fake.Property("PeerGroup1", "id"); found {
+		t.Error("PeerGroup1 is still on the page")
+	}
+	if err := SortPeerTable("latency"); err == nil {
+		t.Error("sorted by an unknown column")
+	}
+}
+
+func TestRenderAddresses(t *testing.T) {
+	fake := useFakeDOM("Address")
+	RenderAddresses([]string{
+		"/ip4/203.0.113.24/tcp/4001",
+		"/ip6/::1/udp/4001/quic",
+		"/dns4/hive.example.com/tcp/443/wss",
+		"/onion3/abc:80",
+	})
+	if got := fake.Children("Address", "textContent"); !reflect.DeepEqual(got, []string{
+		"IPv4 tcp 203.0.113.24:4001",
+		"IPv6 quic [::1]:4001",
+		"IPv4 wss hive.example.com:443",
+		"/onion3/abc:80",
+	}) {
+		t.Errorf("got %q", got)
+	}
+	if got := fake.Children("Address", "title"); got[1] != "/ip6/::1/udp/4001/quic" {
+		t.Errorf("title: got %q", got[1])
+	}
+}
@@ -2,123 +2,103 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
-	"syscall/js"
 )
 
-var (
-	DNSState       bool
-	DriveFreeSpace float64
-)
+func LoadSettings() error {
+	state.Watch("SettingsPage", RenderSettings, KeySettings)
+	log.Debug("Settings Hit")
+	settings, err := Hive().Settings(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Debug(settings)
+	state.SetSettings(*settings)
+	return nil
+}
 
-func GetSettings() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			payload := map[string]interface{}{
-				"val": strings.Join([]string{"hive-cli.exe", "settings", "-g", "-j"}, splicer),
-			}
-			log.Debug("Settings Hit")
-			val := GetData(payload, "GetSettings")
-			var settings Settings
-			err := json.Unmarshal(val, &settings)
-			if err != nil {
-				log.Error("Error in unmarshalling val in GetSettings: ", err.Error())
-				return
-			}
-			log.Debug(settings)
-			SetDisplay("Name", "innerHTML", settings.Name)
-			UsedSpace := settings.UsedStorage
-			sUsedSpace := fmt.Sprintf("%.2f %s", UsedSpace*1024, "MB")
-			SetDisplay("UsedSpace", "innerHTML", sUsedSpace)
-			freeSpace := (settings.MaxStorage - settings.UsedStorage)
-			sFreeSpace := fmt.Sprintf("%.2f %s", freeSpace*1024, "MB")
-			SetDisplay("FreeSpace", "innerHTML", sFreeSpace)
-			SetDisplay("StorageMin", "innerHTML", fmt.Sprintf("%.1f GB", UsedSpace))
-			SetDisplay("rangeSlider", "min", fmt.Sprintf("%.1f", UsedSpace))
-			DriveFreeSpace = settings.FreeDiskSpace / (1024 * 1024 * 1024)
-			log.Debugf("Free Space in Drive: %.1f", DriveFreeSpace)
-			SetDisplay("StorageMax", "innerHTML", fmt.Sprintf("%.1f GB", DriveFreeSpace))
-			SetDisplay("rangeSlider", "max", fmt.Sprintf("%.1f", DriveFreeSpace))
-			MaxStorage := fmt.Sprintf("%.1f", settings.MaxStorage)
-			log.Debugf("MaxStorage: %s", MaxStorage)
-			SetDisplay("rangeSlider", "value", MaxStorage)
-			DNSState = settings.IsDNSEligible
-		}()
-		return nil
-	})
+// RenderSettings renders the name and storage slider of the settings page
+func RenderSettings() {
+	settings, _ := state.Settings()
+	SetDisplay("Name", "innerHTML", settings.Name)
+	UsedSpace := settings.UsedStorage
+	sUsedSpace := fmt.Sprintf("%.2f %s", UsedSpace*1024, "MB")
+	SetDisplay("UsedSpace", "innerHTML", sUsedSpace)
+	freeSpace := (settings.MaxStorage - settings.UsedStorage)
+	sFreeSpace := fmt.Sprintf("%.2f %s", freeSpace*1024, "MB")
+	SetDisplay("FreeSpace", "innerHTML", sFreeSpace)
+	SetDisplay("StorageMin", "innerHTML", fmt.Sprintf("%.1f GB", UsedSpace))
+	SetDisplay("rangeSlider", "min", fmt.Sprintf("%.1f", UsedSpace))
+	driveFreeSpace := settings.FreeDiskSpace / (1024 * 1024 * 1024)
+	log.Debugf("Free Space in Drive: %.1f", driveFreeSpace)
+	SetDisplay("StorageMax", "innerHTML", fmt.Sprintf("%.1f GB", driveFreeSpace))
+	SetDisplay("rangeSlider", "max", fmt.Sprintf("%.1f", driveFreeSpace))
+	MaxStorage := fmt.Sprintf("%.1f", settings.MaxStorage)
+	log.Debugf("MaxStorage: %s", MaxStorage)
+	SetDisplay("rangeSlider", "value", MaxStorage)
 }
 
-func GetStatus() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			payload := map[string]interface{}{
-				"val": strings.Join([]string{"hive-cli.exe", "status", "-j"}, splicer),
-			}
-			log.Debug("GetStatus Hit")
-			val := GetData(payload, "GetStatus")
-			var status Status
-			err := json.Unmarshal(val, &status)
-			if err != nil {
-				log.Error("Error in unmarshalling val in GetStatus: ", err.Error())
-				return
-			}
-			var sValue string
-			if status.LoggedIn == true {
-				sValue = "LoggedIn"
-			} else if status.LoggedIn == false {
-				sValue = "LoggedOut"
-			}
-			SetDisplay("LoggedIn", "innerHTML", sValue)
-			StartTime = status.SessionStartTime
-			CheckBanner()
-		}()
-		return nil
-	})
+func LoadStatus() error {
+	state.Watch("StatusPage", RenderLoginState, KeyStatus)
+	log.Debug("GetStatus Hit")
+	status, err := Hive().Status(context.Background())
+	if err != nil {
+		return err
+	}
+	state.SetStatus(*status)
+	return nil
 }
 
-func GetConfig() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			payload := map[string]interface{}{
-				"val": strings.Join([]string{"hive-cli.exe", "config", "show", "-j"}, splicer),
-			}
-			log.Debug("GetConfig Hit")
-			val := GetData(payload, "GetConfig")
-			var config Config
-			err := json.Unmarshal(val, &config)
-			if err != nil {
-				log.Error("Error in unmarshalling val in GetConfig: ", err.Error())
-				return
-			}
-			log.Debug(config)
-			SetDisplay("SwrmPortNumber", "placeholder", config.SwarmPort)
-			Attributes := make(map[string]string)
-			if DNSState == false {
-				Attributes["style"] = "display: none;"
-				Attributes["aria-hidden"] = "true"
-				Attributes["visibility"] = "hidden"
-				SetMultipleDisplay("Group_62_ID", Attributes)
-				return
-			}
-			SetDisplay("WebSocketPortNumber", "placeholder", config.WebsocketPort)
-		}()
-		return nil
-	})
+// RenderLoginState renders whether the daemon is logged in and the restart banner
+func RenderLoginState() {
+	status, _ := state.Status()
+	err := Bind(status)
+	if err != nil {
+		log.Error("Error in binding Status: ", err.Error())
+	}
+	CheckBanner()
 }
 
-func CheckBanner() {
-	log.Debug("Checking Banner")
-	localStorage := js.Global().Get("localStorage")
-	if !localStorage.Truthy() {
-		log.Error("Unable to get localStorage in CheckBanner")
+// LoadConfig renders the port settings. The websocket port is only shown to
+// DNS eligible nodes, so the view waits for the settings as well.
+func LoadConfig() error {
+	state.Watch("ConfigPage", RenderConfig, KeyConfig, KeySettings)
+	log.Debug("GetConfig Hit")
+	config, err := Hive().Config(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Debug(config)
+	state.SetConfig(*config)
+	return nil
+}
+
+func RenderConfig() {
+	config, _ := state.Config()
+	settings, _ := state.Settings()
+	SetDisplay("SwrmPortNumber", "placeholder", config.SwarmPort)
+	Attributes := make(map[string]string)
+	if settings.IsDNSEligible == false {
+		Attributes["style"] = "display: none;"
+		Attributes["aria-hidden"] = "true"
+		Attributes["visibility"] = "hidden"
+		SetMultipleDisplay("Group_62_ID", Attributes)
 		return
 	}
-	DaemonStartedAt := fmt.Sprintf("%s", localStorage.Get("DaemonStartedAt"))
+	SetMultipleDisplay("Group_62_ID", map[string]string{"style": "", "aria-hidden": "false"})
+	SetDisplay("WebSocketPortNumber", "placeholder", config.WebsocketPort)
+}
+
+func CheckBanner() {
+	log.Debug("Checking Banner")
+	status, _ := state.Status()
+	StartTime := status.SessionStartTime
+	DaemonStartedAt, _ := dom.StorageItem("DaemonStartedAt")
 	sStartTime := fmt.Sprintf("%d", StartTime)
-	sRefreshState := fmt.Sprintf("%s", localStorage.Get("RefreshState"))
+	sRefreshState, _ := dom.StorageItem("RefreshState")
 	log.Debugf("DaemonStartedAt: %s \n StartTime: %s", DaemonStartedAt, sStartTime)
 	if sRefreshState == "Not Refreshed" {
 		if sStartTime == DaemonStartedAt {
@@ -126,13 +106,13 @@ func CheckBanner() {
 			return
 		} else if sStartTime != DaemonStartedAt {
 			SetDisplay("RestartBanner", "style", "display: none;")
-			localStorage.Set("DaemonStartedAt", StartTime)
-			localStorage.Set("RefreshState", "Refreshed")
+			dom.SetStorageItem("DaemonStartedAt", sStartTime)
+			dom.SetStorageItem("RefreshState", "Refreshed")
 			return
 		}
 	}
-	localStorage.Set("DaemonStartedAt", StartTime)
-	localStorage.Set("RefreshState", "Refreshed")
+	dom.SetStorageItem("DaemonStartedAt", sStartTime)
+	dom.SetStorageItem("RefreshState", "Refreshed")
 }
 
 func CheckPort(port string) (status bool, condition string) {
@@ -149,156 +129,118 @@ func CheckPort(port string) (status bool, condition string) {
 	return true, ""
 }
 
-func SaveSettings() {
+func SaveSettings() error {
 	log.Debug("Saving Settings")
-	payload := map[string]interface{}{
-		"val": strings.Join([]string{"hive-cli.exe", "settings", "-j"}, splicer),
-	}
-	val, err := ModifyConfig(payload, "SaveSettings")
+	out, err := Hive().SaveSettings(context.Background())
 	if err != nil {
-		log.Error("Error in Saving Settings")
-		return
+		log.Error("Error in Saving Settings: ", err.Error())
+		return err
 	}
-	log.Debug("Settings Saved: ", val)
-	return
+	log.Debug("Settings Saved: ", out.Message)
+	return nil
 }
 
-func SetSwrmPortNumber() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			log.Debug("Updating SwarmPort Number")
-			SetDisplay("SwrmPortStatus", "innerHTML", "")
-			port := GetValue("SwrmPortNumber", "value")
-			Attributes := make(map[string]string)
-			status, condition := CheckPort(port)
-			if status == true {
-				payload := map[string]interface{}{
-					"val": strings.Join([]string{"hive-cli.exe", "config", "modify", "SwarmPort", port}, splicer),
-				}
-				log.Debugf("Payload in SetSwrmPortNumber: %s", payload)
-				val, _ := ModifyConfig(payload, "SetSwrmPortNumber")
-				if strings.Contains(val, "not") {
-					Attributes["innerHTML"] = fmt.Sprintf("Port %s is Unavailable", port)
-					Attributes["style"] = "color: red;"
-					SetMultipleDisplay("SwrmPortStatus", Attributes)
-					return
-				}
-				SetDisplay("SwrmPortNumber", "placeholder", port)
-				SetDisplay("RestartBanner", "style", "display: block;")
-				Attributes["innerHTML"] = fmt.Sprintf("SwrmPort Changed to %s", port)
-				Attributes["style"] = "color: #32CD32;"
-				SetMultipleDisplay("SwrmPortStatus", Attributes)
-				localStorage := js.Global().Get("localStorage")
-				if !localStorage.Truthy() {
-					log.Error("Unable to get localStorage in SwrmPortNumber")
-					return
-				}
-				localStorage.Set("RefreshState", "Not Refreshed")
-				return
-			} else if status == false {
-				Attributes["innerHTML"] = condition
-				Attributes["style"] = "color: red;"
-				SetMultipleDisplay("SwrmPortStatus", Attributes)
-			}
-		}()
-		return nil
-	})
+// UpdateSwarmPort changes the swarm port to the one entered on the settings page
+func UpdateSwarmPort() {
+	log.Debug("Updating SwarmPort Number")
+	SetDisplay("SwrmPortStatus", "innerHTML", "")
+	port := GetValue("SwrmPortNumber", "value")
+	Attributes := make(map[string]string)
+	status, condition := CheckPort(port)
+	if status == true {
+		log.Debugf("Modifying SwarmPort in SetSwrmPortNumber: %s", port)
+		out, err := Hive().ModifyConfig(context.Background(), "SwarmPort", port)
+		if err != nil || strings.Contains(out.Text(), "not") {
+			Attributes["innerHTML"] = fmt.Sprintf("Port %s is Unavailable", port)
+			Attributes["style"] = "color: red;"
+			SetMultipleDisplay("SwrmPortStatus", Attributes)
+			return
+		}
+		SetDisplay("SwrmPortNumber", "placeholder", port)
+		SetDisplay("RestartBanner", "style", "display: block;")
+		Attributes["innerHTML"] = fmt.Sprintf("SwrmPort Changed to %s", port)
+		Attributes["style"] = "color: #32CD32;"
+		SetMultipleDisplay("SwrmPortStatus", Attributes)
+		Notify(LevelSuccess, "Settings saved", fmt.Sprintf("SwrmPort changed to %s, restart the daemon to apply it", port), "")
+		dom.SetStorageItem("RefreshState", "Not Refreshed")
+		return
+	} else if status == false {
+		Attributes["innerHTML"] = condition
+		Attributes["style"] = "color: red;"
+		SetMultipleDisplay("SwrmPortStatus", Attributes)
+	}
 }
 
-func SetWebsocketPortNumber() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			log.Debug("Updating SetWebsocketPortNumber Number")
-			SetDisplay("WebsocketPortStatus", "innerHTML", "")
-			port := GetValue("WebSocketPortNumber", "value")
-			Attributes := make(map[string]string)
-			status, condition := CheckPort(port)
-			if status == true {
-				payload := map[string]interface{}{
-					"val": strings.Join([]string{"hive-cli.exe", "config", "modify", "WebsocketPort", port}, splicer),
-				}
-				log.Debugf("Payload in SetWebsocketPortNumber: %s", payload)
-				val, _ := ModifyConfig(payload, "SetWebsocketPortNumber")
-				if strings.Contains(val, "not") {
-					Attributes["innerHTML"] = fmt.Sprintf("Port %s is Unavailable", port)
-					Attributes["style"] = "color: red;"
-					SetMultipleDisplay("WebsocketPortStatus", Attributes)
-					return
-				}
-				log.Debug("SwrmPort Updated Successfully")
-				SetDisplay("WebSocketPortNumber", "placeholder", port)
-				SetDisplay("RestartBanner", "style", "display: block;")
-				Attributes["innerHTML"] = fmt.Sprintf("WebsocketPort Changed to %s", port)
-				Attributes["style"] = "color: #32CD32;"
-				SetMultipleDisplay("WebsocketPortStatus", Attributes)
-				localStorage := js.Global().Get("localStorage")
-				if !localStorage.Truthy() {
-					log.Error("Unable to get localStorage in WebsocketPortNumber")
-					return
-				}
-				localStorage.Set("RefreshState", "Not Refreshed")
-				return
-			} else if status == false {
-				Attributes["innerHTML"] = condition
-				Attributes["style"] = "color: red;"
-				SetMultipleDisplay("WebsocketPortStatus", Attributes)
-			}
-		}()
-		return nil
-	})
+// UpdateWebsocketPort changes the websocket port to the one entered on the settings page
+func UpdateWebsocketPort() {
+	log.Debug("Updating SetWebsocketPortNumber Number")
+	SetDisplay("WebsocketPortStatus", "innerHTML", "")
+	port := GetValue("WebSocketPortNumber", "value")
+	Attributes := make(map[string]string)
+	status, condition := CheckPort(port)
+	if status == true {
+		log.Debugf("Modifying WebsocketPort in SetWebsocketPortNumber: %s", port)
+		out, err := Hive().ModifyConfig(context.Background(), "WebsocketPort", port)
+		if err != nil || strings.Contains(out.Text(), "not") {
+			Attributes["innerHTML"] = fmt.Sprintf("Port %s is Unavailable", port)
+			Attributes["style"] = "color: red;"
+			SetMultipleDisplay("WebsocketPortStatus", Attributes)
+			return
+		}
+		log.Debug("SwrmPort Updated Successfully")
+		SetDisplay("WebSocketPortNumber", "placeholder", port)
+		SetDisplay("RestartBanner", "style", "display: block;")
+		Attributes["innerHTML"] = fmt.Sprintf("WebsocketPort Changed to %s", port)
+		Attributes["style"] = "color: #32CD32;"
+		SetMultipleDisplay("WebsocketPortStatus", Attributes)
+		Notify(LevelSuccess, "Settings saved", fmt.Sprintf("WebsocketPort changed to %s, restart the daemon to apply it", port), "")
+		dom.SetStorageItem("RefreshState", "Not Refreshed")
+		return
+	} else if status == false {
+		Attributes["innerHTML"] = condition
+		Attributes["style"] = "color: red;"
+		SetMultipleDisplay("WebsocketPortStatus", Attributes)
+	}
 }
 
-func VerifyPort() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			log.Debug("Verifying Port Forwarding....")
-			Attributes := make(map[string]string)
-			Attributes["innerHTML"] = "Verifying...."
-			Attributes["style"] = "color: rgba(219,219,219,1);"
-			SetMultipleDisplay("PortForward", Attributes)
-			payload := map[string]interface{}{
-				"val": strings.Join([]string{"hive-cli.exe", "verify-port-forward"}, splicer),
-			}
-			val, err := ModifyConfig(payload, "VerifyPort")
-			if err != nil {
-				log.Error("Error in Checking Port Forwarding Status")
-				SetDisplay("PortForward", "innerHTML", "Error in Checking")
-				return
-			}
-			log.Debugf("This is val: %s", val)
-			if strings.Contains(val, "NOT") {
-				log.Debug("Port Forward Not Verified")
-				Attributes["innerHTML"] = "Not Forwarded &#10008;"
-				Attributes["style"] = "color: rgba(244,105,50,1);"
-				SetMultipleDisplay("PortForward", Attributes)
-				return
-			}
-			log.Debug("Port Forward Verified")
-			Attributes["innerHTML"] = "Port Forwarded &#10004;"
-			Attributes["style"] = "color: rgba(244,105,50,1);"
-			SetMultipleDisplay("PortForward", Attributes)
-		}()
-		return nil
-	})
+// CheckPortForward asks the daemon whether the swarm port is forwarded
+func CheckPortForward() {
+	log.Debug("Verifying Port Forwarding....")
+	Attributes := make(map[string]string)
+	Attributes["innerHTML"] = "Verifying...."
+	Attributes["style"] = "color: rgba(219,219,219,1);"
+	SetMultipleDisplay("PortForward", Attributes)
+	val, err := Hive().VerifyPortForward(context.Background())
+	if err != nil {
+		log.Error("Error in Checking Port Forwarding Status: ", err.Error())
+		SetDisplay("PortForward", "innerHTML", "Error in Checking")
+		return
+	}
+	log.Debugf("This is val: %s", val)
+	if strings.Contains(val, "NOT") {
+		log.Debug("Port Forward Not Verified")
+		Attributes["innerHTML"] = "Not Forwarded &#10008;"
+		Attributes["style"] = "color: rgba(244,105,50,1);"
+		SetMultipleDisplay("PortForward", Attributes)
+		return
+	}
+	log.Debug("Port Forward Verified")
+	Attributes["innerHTML"] = "Port Forwarded &#10004;"
+	Attributes["style"] = "color: rgba(244,105,50,1);"
+	SetMultipleDisplay("PortForward", Attributes)
 }
 
-func ModifyStorageSize() js.Func {
-	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		go func() {
-			val := GetValue("rangeSlider", "value")
-			log.Debug("Changing Storage Size to: ", val)
-			payload := map[string]interface{}{
-				"val": strings.Join([]string{"hive-cli.exe", "config", "modify", "Storage", val}, splicer),
-			}
-			log.Debug("Payload in Modify Storage Size: ", payload)
-			val, err := ModifyConfig(payload, "ModifyStorageSize")
-			if err != nil {
-				log.Error("Error in Modifying Storage Size", err.Error())
-				return
-			}
-			log.Debug("val in ModifyStorageSize: ", val)
-			SaveSettings()
-		}()
-		return nil
-	})
+// UpdateStorageSize changes the storage size to the slider value and saves the settings
+func UpdateStorageSize() {
+	val := GetValue("rangeSlider", "value")
+	log.Debug("Changing Storage Size to: ", val)
+	out, err := Hive().ModifyConfig(context.Background(), "Storage", val)
+	if err != nil {
+		log.Error("Error in Modifying Storage Size: ", err.Error())
+		return
+	}
+	log.Debug("Storage Size Modified: ", out.Message)
+	if SaveSettings() == nil {
+		Notify(LevelSuccess, "Settings saved", fmt.Sprintf("Storage size changed to %s GB", val), "")
+	}
 }
@@ -0,0 +1,49 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"syscall/js"
+)
+
+func GetSettings() js.Func {
+	return loader("GetSettings", LoadSettings)
+}
+
+func GetStatus() js.Func {
+	return loader("GetStatus", LoadStatus)
+}
+
+func GetConfig() js.Func {
+	return loader("GetConfig", LoadConfig)
+}
+
+func SetSwrmPortNumber() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go UpdateSwarmPort()
+		return nil
+	})
+}
+
+func SetWebsocketPortNumber() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go UpdateWebsocketPort()
+		return nil
+	})
+}
+
+func VerifyPort() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go CheckPortForward()
+		return nil
+	})
+}
+
+func ModifyStorageSize() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go UpdateStorageSize()
+		return nil
+	})
+}
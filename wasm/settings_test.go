@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckPort(t *testing.T) {
+	for _, tc := range []struct {
+		port      string
+		ok        bool
+		condition string
+	}{
+		{"4001", true, ""},
+		{"1025", true, ""},
+		{"49150", true, ""},
+		{"1024", false, "Port 1024 is Unavailable"},
+		{"49151", false, "Port 49151 is Unavailable"},
+		{"-4001", false, "Port -4001 is Unavailable"},
+		{"0", false, "Port 0 is Unavailable"},
+		{"", false, "Enter A Valid Port Number"},
+		{"40o1", false, "Port 40o1 is Not a Number"},
+		{"4001.5", false, "Port 4001.5 is Not a Number"},
+		{"99999999999999999999", false, "Port 99999999999999999999 is Not a Number"},
+	} {
+		ok, condition := CheckPort(tc.port)
+		if ok != tc.ok || condition != tc.condition {
+			t.Errorf("CheckPort(%q): got %v %q, want %v %q", tc.port, ok, condition, tc.ok, tc.condition)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"fmt"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+const (
+	PageDashboard = "dashboard"
+	PageSettings  = "settings"
+
+	// UptimeInterval is how often the dashboard updates the uptime counter
+	UptimeInterval = 1 * time.Second
+)
+
+// Load is a named initial load of a page
+type Load struct {
+	Name string
+	Run  func() error
+}
+
+// Stages lists the initial loads of every page. Loads within a stage run
+// concurrently, a stage only starts once the previous one has finished.
+var Stages = map[string][][]Load{
+	PageDashboard: {
+		{
+			{"Version", LoadVersion},
+			{"StorageLocation", LoadStorageLocation},
+			{"Profile", LoadProfile},
+			{"ID", LoadID},
+			{"Bandwidth", LoadBandwidth},
+			{"EarningDropDown", LoadEarningDropDown},
+		},
+		{
+			{"EarningGraph", LoadEarningGraph},
+		},
+	},
+	PageSettings: {
+		{
+			{"StorageLocation", LoadStorageLocation},
+			{"Version", LoadVersion},
+			{"Status", LoadStatus},
+			{"Settings", LoadSettings},
+		},
+		{
+			{"Config", LoadConfig},
+			{"SliderColour", LoadSliderColour},
+		},
+	},
+}
+
+var (
+	pollersOnce sync.Once
+	historyOnce sync.Once
+)
+
+// Start runs the initial loads of a page, "dashboard" by default or
+// "settings", once the document is parsed and the daemon client is ready. It
+// returns a promise resolved with the names and errors of the failed loads,
+// after which window.hiveReady is true and a "hive-ready" event is dispatched
+// on window.
+func Start() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		page := PageDashboard
+		if len(args) > 0 && args[0].Type() == js.TypeString {
+			page = args[0].String()
+		}
+		stages, found := Stages[page]
+		if !found {
+			return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New(fmt.Sprintf("unknown page %q", page)))
+		}
+		handler := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			resolve := args[0]
+			go func() {
+				failed := StartPage(page, stages)
+				result := make(map[string]interface{})
+				for name, err := range failed {
+					result[name] = err.Error()
+				}
+				detail := map[string]interface{}{"page": page, "errors": result}
+				js.Global().Set("hiveReady", true)
+				event := js.Global().Get("CustomEvent").New("hive-ready", map[string]interface{}{"detail": detail})
+				js.Global().Call("dispatchEvent", event)
+				resolve.Invoke(detail)
+			}()
+			return nil
+		})
+		return js.Global().Get("Promise").New(handler)
+	})
+}
+
+// StartPage waits for the document and the daemon client, opens the history,
+// starts the event stream and pollers of the dashboard, and runs the stages
+// of the page. It returns the errors of the failed loads by name.
+func StartPage(page string, stages [][]Load) map[string]error {
+	WaitForDocument()
+	Hive()
+	if page == PageDashboard {
+		historyOnce.Do(OpenHistory)
+		WatchDashboard()
+		StartEvents()
+		pollersOnce.Do(StartPollers)
+	}
+	failed := make(map[string]error)
+	var mtx sync.Mutex
+	for _, stage := range stages {
+		var wg sync.WaitGroup
+		for _, load := range stage {
+			wg.Add(1)
+			go func(load Load) {
+				defer wg.Done()
+				err := load.Run()
+				if err != nil {
+					log.Errorf("Error in loading %s: %s", load.Name, err.Error())
+					mtx.Lock()
+					failed[load.Name] = err
+					mtx.Unlock()
+				}
+			}(load)
+		}
+		wg.Wait()
+	}
+	log.Debugf("Started %s with %d failed loads", page, len(failed))
+	return failed
+}
+
+// WaitForDocument blocks until the document has been parsed, so every
+// element a load renders into exists.
+func WaitForDocument() {
+	jsDoc := js.Global().Get("document")
+	if !jsDoc.Truthy() || jsDoc.Get("readyState").String() != "loading" {
+		return
+	}
+	ready := make(chan struct{})
+	var onReady js.Func
+	onReady = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		jsDoc.Call("removeEventListener", "DOMContentLoaded", onReady)
+		onReady.Release()
+		close(ready)
+		return nil
+	})
+	jsDoc.Call("addEventListener", "DOMContentLoaded", onReady)
+	<-ready
+}
+
+// StartPollers refreshes the bandwidth and uptime of the dashboard
+func StartPollers() {
+	go func() {
+		ticker := time.NewTicker(BandwidthInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			err := LoadBandwidth()
+			if err != nil {
+				log.Error("Error in polling Bandwidth: ", err.Error())
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(UptimeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			RenderUptime()
+		}
+	}()
+}
+
+// LoadEarningGraph draws the earnings graph for the selected device, so it
+// has to run after LoadEarningDropDown.
+func LoadEarningGraph() error {
+	return callPageFunction("CreateGraph", GetValue("DevicesDropDown", "value"))
+}
+
+// LoadSliderColour paints the storage slider, so it has to run after
+// LoadSettings set its range.
+func LoadSliderColour() error {
+	return callPageFunction("SliderColour")
+}
+
+func callPageFunction(name string, args ...interface{}) (err error) {
+	fn := js.Global().Get(name)
+	if fn.Type() != js.TypeFunction {
+		return fmt.Errorf("%s is not defined on the page", name)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", name, r)
+		}
+	}()
+	fn.Invoke(args...)
+	return nil
+}
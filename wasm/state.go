@@ -0,0 +1,210 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"sync"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+// StateKey names a value held by the Store
+type StateKey string
+
+const (
+	KeyStatus     StateKey = "Status"
+	KeySettings   StateKey = "Settings"
+	KeyConfig     StateKey = "Config"
+	KeyBalance    StateKey = "Balance"
+	KeyBCNBalance StateKey = "BCNBalance"
+	KeySettlement StateKey = "Settlement"
+	KeyPeerCount  StateKey = "PeerCount"
+	KeyPeers      StateKey = "Peers"
+	KeyBandwidth  StateKey = "Bandwidth"
+)
+
+// Store holds the latest daemon state, whether it came from an event or a
+// command. Values are replaced as a whole, so a getter never observes a value
+// that is being written.
+type Store struct {
+	mtx      sync.RWMutex
+	values   map[StateKey]interface{}
+	watchers map[string]*watcher
+}
+
+type watcher struct {
+	keys []StateKey
+	fn   func()
+}
+
+var state = NewStore()
+
+func NewStore() *Store {
+	return &Store{
+		values:   make(map[StateKey]interface{}),
+		watchers: make(map[string]*watcher),
+	}
+}
+
+// Watch calls fn whenever one of keys changes, as soon as all of them have a
+// value. fn is also called right away if they already do. Watching again
+// under the same name replaces the previous watcher, so views can be watched
+// every time the page asks for them.
+func (s *Store) Watch(name string, fn func(), keys ...StateKey) {
+	s.mtx.Lock()
+	w := &watcher{keys: keys, fn: fn}
+	s.watchers[name] = w
+	ready := s.has(keys)
+	s.mtx.Unlock()
+	if ready {
+		fn()
+	}
+}
+
+// Unwatch removes the watcher registered under name
+func (s *Store) Unwatch(name string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.watchers, name)
+}
+
+// Has reports whether every key has a value
+func (s *Store) Has(keys ...StateKey) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.has(keys)
+}
+
+func (s *Store) has(keys []StateKey) bool {
+	for _, key := range keys {
+		if _, found := s.values[key]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// set stores value and calls the watchers of key whose inputs are all
+// available. Watchers run in the caller's goroutine after the lock is released.
+func (s *Store) set(key StateKey, value interface{}) {
+	s.mtx.Lock()
+	s.values[key] = value
+	var ready []func()
+	for _, w := range s.watchers {
+		if watches(w.keys, key) && s.has(w.keys) {
+			ready = append(ready, w.fn)
+		}
+	}
+	s.mtx.Unlock()
+	for _, fn := range ready {
+		fn()
+	}
+}
+
+func (s *Store) get(key StateKey) (interface{}, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	value, found := s.values[key]
+	return value, found
+}
+
+func watches(keys []StateKey, key StateKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) SetStatus(status client.Status) { s.set(KeyStatus, status) }
+
+func (s *Store) Status() (client.Status, bool) {
+	value, found := s.get(KeyStatus)
+	if !found {
+		return client.Status{}, false
+	}
+	return value.(client.Status), true
+}
+
+func (s *Store) SetSettings(settings client.Settings) { s.set(KeySettings, settings) }
+
+func (s *Store) Settings() (client.Settings, bool) {
+	value, found := s.get(KeySettings)
+	if !found {
+		return client.Settings{}, false
+	}
+	return value.(client.Settings), true
+}
+
+func (s *Store) SetConfig(config client.Config) { s.set(KeyConfig, config) }
+
+func (s *Store) Config() (client.Config, bool) {
+	value, found := s.get(KeyConfig)
+	if !found {
+		return client.Config{}, false
+	}
+	return value.(client.Config), true
+}
+
+func (s *Store) SetBalance(balance float64) { s.set(KeyBalance, balance) }
+
+func (s *Store) Balance() (float64, bool) {
+	value, found := s.get(KeyBalance)
+	if !found {
+		return 0, false
+	}
+	return value.(float64), true
+}
+
+func (s *Store) SetBCNBalance(bcnBalance client.BCNBalance) { s.set(KeyBCNBalance, bcnBalance) }
+
+func (s *Store) BCNBalance() (client.BCNBalance, bool) {
+	value, found := s.get(KeyBCNBalance)
+	if !found {
+		return client.BCNBalance{}, false
+	}
+	return value.(client.BCNBalance), true
+}
+
+func (s *Store) SetSettlement(settlement client.Settlement) { s.set(KeySettlement, settlement) }
+
+func (s *Store) Settlement() (client.Settlement, bool) {
+	value, found := s.get(KeySettlement)
+	if !found {
+		return client.Settlement{}, false
+	}
+	return value.(client.Settlement), true
+}
+
+func (s *Store) SetPeerCount(count int) { s.set(KeyPeerCount, count) }
+
+func (s *Store) PeerCount() (int, bool) {
+	value, found := s.get(KeyPeerCount)
+	if !found {
+		return 0, false
+	}
+	return value.(int), true
+}
+
+// SetPeers stores a copy of the connected peer multiaddrs
+func (s *Store) SetPeers(peers []string) {
+	s.set(KeyPeers, append([]string(nil), peers...))
+}
+
+func (s *Store) Peers() ([]string, bool) {
+	value, found := s.get(KeyPeers)
+	if !found {
+		return nil, false
+	}
+	return append([]string(nil), value.([]string)...), true
+}
+
+func (s *Store) SetBandwidth(bandwidth client.Bandwidth) { s.set(KeyBandwidth, bandwidth) }
+
+func (s *Store) Bandwidth() (client.Bandwidth, bool) {
+	value, found := s.get(KeyBandwidth)
+	if !found {
+		return client.Bandwidth{}, false
+	}
+	return value.(client.Bandwidth), true
+}
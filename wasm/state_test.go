@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/StreamSpace/hive-wasm-client/client"
+)
+
+func TestStoreWatch(t *testing.T) {
+	s := NewStore()
+	var calls []string
+	watch := func(name string, keys ...StateKey) {
+		s.Watch(name, func() { calls = append(calls, name) }, keys...)
+	}
+
+	// registered before any value is set
+	watch("balance", KeyBalance)
+	watch("wallet", KeyBalance, KeyBCNBalance)
+	if len(calls) != 0 {
+		t.Errorf("watchers ran without values: %v", calls)
+	}
+	s.SetBalance(1.5)
+	if !reflect.DeepEqual(calls, []string{"balance"}) {
+		t.Errorf("got %v, want [balance]", calls)
+	}
+	// wallet runs once all of its keys have a value
+	calls = nil
+	s.SetBCNBalance(client.BCNBalance{})
+	if !reflect.DeepEqual(calls, []string{"wallet"}) {
+		t.Errorf("got %v, want [wallet]", calls)
+	}
+
+	// registered after set, it runs right away
+	calls = nil
+	watch("late", KeyBalance)
+	if !reflect.DeepEqual(calls, []string{"late"}) {
+		t.Errorf("got %v, want [late]", calls)
+	}
+
+	// watching again under the same name replaces the watcher
+	calls = nil
+	watch("late", KeyPeerCount)
+	s.SetBalance(2)
+	if len(calls) != 2 || calls[0] == "late" || calls[1] == "late" {
+		t.Errorf("got %v, want balance and wallet", calls)
+	}
+
+	calls = nil
+	s.Unwatch("balance")
+	s.Unwatch("wallet")
+	s.SetBalance(3)
+	if len(calls) != 0 {
+		t.Errorf("unwatched: got %v", calls)
+	}
+}
+
+func TestStoreFound(t *testing.T) {
+	s := NewStore()
+	if _, found := s.Status(); found {
+		t.Error("status found before it was set")
+	}
+	if _, found := s.PeerCount(); found {
+		t.Error("peer count found before it was set")
+	}
+	if s.Has(KeyPeerCount, KeyPeers) {
+		t.Error("Has without values")
+	}
+	// a zero value is a value
+	s.SetPeerCount(0)
+	if count, found := s.PeerCount(); !found || count != 0 {
+		t.Errorf("got %d, %v", count, found)
+	}
+	if s.Has(KeyPeerCount, KeyPeers) {
+		t.Error("Has with one of two values")
+	}
+	s.SetPeers(nil)
+	if !s.Has(KeyPeerCount, KeyPeers) {
+		t.Error("Has with both values")
+	}
+	if !s.Has() {
+		t.Error("Has without keys")
+	}
+
+	// peers are copied in and out
+	peers := []string{"/ip4/198.51.100.7/tcp/4001/p2p/QmA"}
+	s.SetPeers(peers)
+	peers[0] = "changed"
+	got, _ := s.Peers()
+	got[0] = "changed too"
+	if got, _ := s.Peers(); got[0] != "/ip4/198.51.100.7/tcp/4001/p2p/QmA" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStoreWatchersRunUnlocked(t *testing.T) {
+	s := NewStore()
+	var got float64
+	s.Watch("reader", func() {
+		// reading, writing or watching from a watcher would deadlock under the lock
+		got, _ = s.Balance()
+		s.SetPeerCount(1)
+		s.Watch("nested", func() {}, KeyPeerCount)
+	}, KeyBalance)
+	s.SetBalance(4.2)
+	if got != 4.2 {
+		t.Errorf("got %v", got)
+	}
+	if count, _ := s.PeerCount(); count != 1 {
+		t.Errorf("peer count: got %d", count)
+	}
+}
+
+func TestStoreConcurrentSet(t *testing.T) {
+	s := NewStore()
+	var mtx sync.Mutex
+	calls := 0
+	s.Watch("counter", func() {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+	}, KeyPeerCount)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			s.SetPeerCount(i)
+			s.PeerCount()
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			s.SetPeers([]string{peerAddr("QmA")})
+			s.Peers()
+			s.Watch("peers", func() {}, KeyPeers)
+		}(i)
+	}
+	wg.Wait()
+	if calls != 50 {
+		t.Errorf("got %d watcher calls, want 50", calls)
+	}
+}
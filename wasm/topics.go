@@ -0,0 +1,60 @@
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TopicHandler receives the data of every event published on a topic
+type TopicHandler func(json.RawMessage) error
+
+var (
+	topicsMtx     sync.RWMutex
+	topicHandlers = make(map[string][]TopicHandler)
+)
+
+// RegisterTopicHandler subscribes fn to a daemon event topic. A topic can
+// have any number of handlers, they are called in registration order.
+func RegisterTopicHandler(topic string, fn func(json.RawMessage) error) {
+	topicsMtx.Lock()
+	defer topicsMtx.Unlock()
+	topicHandlers[topic] = append(topicHandlers[topic], fn)
+}
+
+// RegisterDefaultTopicHandlers subscribes the dashboard widgets to the topics
+// published by the daemon.
+func RegisterDefaultTopicHandlers() {
+	RegisterTopicHandler("Status", StatusEvent)
+	RegisterTopicHandler("Balance", BalanceEvent)
+	RegisterTopicHandler("Settlement", SettlementEvent)
+	RegisterTopicHandler("BalanceCycle", BalanceCycleEvent)
+	RegisterTopicHandler("Peers", PeersEvent)
+	RegisterTopicHandler("Settings", SettingsEvent)
+}
+
+// HandleEvent passes the data of an event to every handler of its topic. A
+// failing handler does not stop the others from running.
+func HandleEvent(topic string, val []byte) error {
+	topicsMtx.RLock()
+	handlers := make([]TopicHandler, len(topicHandlers[topic]))
+	copy(handlers, topicHandlers[topic])
+	topicsMtx.RUnlock()
+	if len(handlers) == 0 {
+		log.Debugf("No handler for topic: %s", topic)
+		return nil
+	}
+	var errs []string
+	for _, handler := range handlers {
+		err := handler(json.RawMessage(val))
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s: %s", topic, strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build js
+// +build js
+
+// GOOS=js GOARCH=wasm go build -o  ../assets/hive.wasm
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// OnHiveEvent lets JS widgets subscribe to a topic with
+// OnHiveEvent(topic, callback). The callback receives the parsed event data.
+func OnHiveEvent() js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeFunction {
+			return js.Global().Get("Error").New("OnHiveEvent expects a topic and a callback")
+		}
+		topic := args[0].String()
+		callback := args[1]
+		RegisterTopicHandler(topic, func(val json.RawMessage) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("OnHiveEvent callback for %s: %v", topic, r)
+				}
+			}()
+			data := js.Global().Get("JSON").Call("parse", string(val))
+			callback.Invoke(data)
+			return nil
+		})
+		return nil
+	})
+}